@@ -0,0 +1,844 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/yixinin/postcard-go/internal/fieldtag"
+	"github.com/yixinin/postcard-go/internal/load"
+)
+
+const postcardPath = "github.com/yixinin/postcard-go/postcard"
+
+const header = "// Code generated by postcard-gen. DO NOT EDIT.\n\n"
+
+type basicCodec struct {
+	method string
+	goType string
+	// size is a Go expression for the encoded size; %s is the value.
+	size string
+}
+
+var basicCodecs = map[types.BasicKind]basicCodec{
+	types.Bool:       {"Bool", "bool", "1"},
+	types.Int8:       {"Int8", "int8", "1"},
+	types.Int16:      {"Int16", "int16", "postcard.SizeOfInt(int64(%s))"},
+	types.Int32:      {"Int32", "int32", "postcard.SizeOfInt(int64(%s))"},
+	types.Int64:      {"Int64", "int64", "postcard.SizeOfInt(int64(%s))"},
+	types.Int:        {"Int", "int", "postcard.SizeOfInt(int64(%s))"},
+	types.Uint8:      {"Uint8", "uint8", "1"},
+	types.Uint16:     {"Uint16", "uint16", "postcard.SizeOfUint(uint64(%s))"},
+	types.Uint32:     {"Uint32", "uint32", "postcard.SizeOfUint(uint64(%s))"},
+	types.Uint64:     {"Uint64", "uint64", "postcard.SizeOfUint(uint64(%s))"},
+	types.Uint:       {"Uint", "uint", "postcard.SizeOfUint(uint64(%s))"},
+	types.Float32:    {"Float32", "float32", "4"},
+	types.Float64:    {"Float64", "float64", "8"},
+	types.Complex64:  {"Complex64", "complex64", "8"},
+	types.Complex128: {"Complex128", "complex128", "16"},
+	types.String:     {"String", "string", "postcard.SizeOfString(%s)"},
+}
+
+type generator struct {
+	pkg     *load.Package
+	imports map[string]string
+	buf     bytes.Buffer
+	tmp     int
+}
+
+func newGenerator(pkg *load.Package) *generator {
+	return &generator{
+		pkg:     pkg,
+		imports: map[string]string{postcardPath: "postcard"},
+	}
+}
+
+// Generate returns the formatted source of the MarshalPostcard,
+// UnmarshalPostcard and SizePostcard methods for every marked type.
+func Generate(pkg *load.Package) ([]byte, error) {
+	g := newGenerator(pkg)
+	for _, obj := range pkg.Marked {
+		if err := g.genType(obj); err != nil {
+			return nil, err
+		}
+	}
+	return g.source()
+}
+
+func (g *generator) source() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString(header)
+	fmt.Fprintf(&out, "package %s\n\n", g.pkg.Types.Name())
+
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	out.WriteString("import (\n")
+	for _, path := range paths {
+		fmt.Fprintf(&out, "%q\n", path)
+	}
+	out.WriteString(")\n\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+func (g *generator) genType(obj *types.TypeName) error {
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return fmt.Errorf("%s: only defined types can be generated", obj.Name())
+	}
+	if named.TypeParams().Len() > 0 {
+		return fmt.Errorf("%s: generic types are not supported", obj.Name())
+	}
+
+	name := obj.Name()
+	_, isStruct := named.Underlying().(*types.Struct)
+	ptr := "(*v)"
+	if isStruct {
+		ptr = "v"
+	}
+
+	g.printf("// MarshalPostcard encodes v without reflection.\n")
+	g.printf("func (v %s) MarshalPostcard(s *postcard.Serializer) error {\n", name)
+	if err := g.encodeUnderlying("v", named); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	g.printf("return nil\n}\n\n")
+
+	g.printf("// UnmarshalPostcard decodes v without reflection.\n")
+	g.printf("func (v *%s) UnmarshalPostcard(d *postcard.Deserializer) error {\n", name)
+	if err := g.decodeUnderlying(ptr, named); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	g.printf("return nil\n}\n\n")
+
+	g.printf("// SizePostcard returns the encoded size of v.\n")
+	g.printf("func (v %s) SizePostcard() int {\n", name)
+	g.printf("n := 0\n")
+	g.sizeUnderlying("v", named)
+	g.printf("return n\n}\n\n")
+	return nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) check(call string) {
+	g.printf("if err := %s; err != nil {\nreturn err\n}\n", call)
+}
+
+func (g *generator) temp(prefix string) string {
+	g.tmp++
+	return fmt.Sprintf("%s%d", prefix, g.tmp)
+}
+
+func (g *generator) qualifier(p *types.Package) string {
+	if p == g.pkg.Types {
+		return ""
+	}
+	g.imports[p.Path()] = p.Name()
+	return p.Name()
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+// convert wraps expr, of type t, in a conversion to goType when t is a
+// defined type.
+func convert(expr string, t types.Type, goType string) string {
+	if _, ok := t.(*types.Named); !ok {
+		return expr
+	}
+	return goType + "(" + expr + ")"
+}
+
+func isVarint(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == postcardPath && obj.Name() == "Varint"
+}
+
+// timeWrapper returns the postcard type that encodes t if t is time.Duration
+// or time.Time, as SerializeValue does.
+func timeWrapper(t types.Type) string {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "time" {
+		return ""
+	}
+	switch named.Obj().Name() {
+	case "Duration":
+		return "postcard.Duration"
+	case "Time":
+		return "postcard.UnixSeconds"
+	}
+	return ""
+}
+
+// netipCodec returns the suffix of the Serializer and Deserializer methods
+// for t if t is netip.Addr, netip.AddrPort or netip.Prefix.
+func netipCodec(t types.Type) string {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "net/netip" {
+		return ""
+	}
+	switch name := named.Obj().Name(); name {
+	case "Addr", "AddrPort", "Prefix":
+		return name
+	}
+	return ""
+}
+
+// unixNames maps the units of `postcard:"unix=..."` to their postcard type.
+var unixNames = map[string]string{
+	"s":  "postcard.UnixSeconds",
+	"ms": "postcard.UnixMillis",
+	"us": "postcard.UnixMicros",
+	"ns": "postcard.UnixNanos",
+}
+
+func (g *generator) hasMethod(t types.Type, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	if g.pkg.IsMarked(named.Obj()) {
+		return true
+	}
+	if name == "UnmarshalPostcard" {
+		t = types.NewPointer(t)
+	}
+	return types.NewMethodSet(t).Lookup(nil, name) != nil
+}
+
+// fixintNames maps integer kinds to the suffix of their postcard.Fix* type.
+var fixintNames = map[types.BasicKind]struct {
+	name string
+	size int
+}{
+	types.Int16:  {"I16", 2},
+	types.Int32:  {"I32", 4},
+	types.Int64:  {"I64", 8},
+	types.Uint16: {"U16", 2},
+	types.Uint32: {"U32", 4},
+	types.Uint64: {"U64", 8},
+}
+
+// tagWrapper returns the postcard type that encodes field i of s as its tag
+// asks: a postcard.Fix* type for `postcard:"fixint=le"` or
+// `postcard:"fixint=be"`, postcard.Char for `postcard:"char"`, or a
+// postcard.Unix* type for `postcard:"unix=ms"` and the like. size is the
+// encoded size, or 0 if it depends on the value.
+func tagWrapper(s *types.Struct, i int) (wrapper string, size int, err error) {
+	tag, err := fieldtag.Parse(reflect.StructTag(s.Tag(i)))
+	if err != nil {
+		return "", 0, err
+	}
+	t := s.Field(i).Type()
+	b, _ := t.Underlying().(*types.Basic)
+	switch {
+	case tag.Fixint != "":
+		if b != nil {
+			if n, ok := fixintNames[b.Kind()]; ok {
+				return "postcard.Fix" + n.name + strings.ToUpper(tag.Fixint), n.size, nil
+			}
+		}
+		return "", 0, fmt.Errorf("fixint needs a 16, 32 or 64-bit integer, got %s", t)
+	case tag.Char:
+		if b == nil || b.Kind() != types.Int32 {
+			return "", 0, fmt.Errorf("char needs a rune, got %s", t)
+		}
+		return "postcard.Char", 0, nil
+	case tag.Unix != "":
+		if timeWrapper(t) != "postcard.UnixSeconds" {
+			return "", 0, fmt.Errorf("unix needs a time.Time, got %s", t)
+		}
+		return unixNames[tag.Unix], 0, nil
+	}
+	return "", 0, nil
+}
+
+// byteArrayTag reports the length of field i of s if the field is a byte
+// array tagged `postcard:"bytes"`, which encodes as length-prefixed bytes
+// instead of a tuple. The tag changes nothing on byte slices.
+func byteArrayTag(s *types.Struct, i int) (int64, bool, error) {
+	tag, err := fieldtag.Parse(reflect.StructTag(s.Tag(i)))
+	if err != nil || !tag.Bytes {
+		return 0, false, err
+	}
+	switch u := s.Field(i).Type().Underlying().(type) {
+	case *types.Array:
+		if b, ok := u.Elem().(*types.Basic); ok && b.Kind() == types.Uint8 {
+			return u.Len(), true, nil
+		}
+	case *types.Slice:
+		if isByteSlice(u) {
+			return 0, false, nil
+		}
+	}
+	return 0, false, fmt.Errorf("bytes needs a byte array, got %s", s.Field(i).Type())
+}
+
+// optionElem returns T if t is postcard.Option[T].
+func optionElem(t types.Type) (types.Type, bool) {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != postcardPath || named.Obj().Name() != "Option" {
+		return nil, false
+	}
+	return named.TypeArgs().At(0), true
+}
+
+// maxTag reports the bound of field i of s if it is a slice, string or map,
+// or an Option of one, tagged `postcard:"max=N"`.
+func maxTag(s *types.Struct, i int) (int, bool, error) {
+	tag, err := fieldtag.Parse(reflect.StructTag(s.Tag(i)))
+	if err != nil || tag.Max == 0 {
+		return 0, false, err
+	}
+	t := s.Field(i).Type()
+	if elem, ok := optionElem(t); ok {
+		t = elem
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice, *types.Map:
+		return tag.Max, true, nil
+	case *types.Basic:
+		if u.Kind() == types.String {
+			return tag.Max, true, nil
+		}
+	}
+	return 0, false, fmt.Errorf("max needs a slice, string or map, got %s", s.Field(i).Type())
+}
+
+// byteArraySize is the encoded size of n length-prefixed bytes.
+func byteArraySize(n int64) int {
+	size := int(n) + 1
+	for n >= 0x80 {
+		size++
+		n >>= 7
+	}
+	return size
+}
+
+func isByteSlice(s *types.Slice) bool {
+	b, ok := s.Elem().(*types.Basic)
+	return ok && b.Kind() == types.Uint8
+}
+
+func isNamedByteSlice(s *types.Slice) bool {
+	b, ok := s.Elem().Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Uint8 && !isByteSlice(s)
+}
+
+func (g *generator) encode(expr string, t types.Type) error {
+	switch {
+	case isVarint(t):
+		g.check(fmt.Sprintf("s.SerializeVarInt(%s)", expr))
+		return nil
+	case timeWrapper(t) != "":
+		g.check(fmt.Sprintf("%s(%s).MarshalPostcard(s)", timeWrapper(t), expr))
+		return nil
+	case netipCodec(t) != "":
+		g.check(fmt.Sprintf("s.Serialize%s(%s)", netipCodec(t), expr))
+		return nil
+	case g.hasMethod(t, "MarshalPostcard"):
+		g.check(expr + ".MarshalPostcard(s)")
+		return nil
+	}
+	return g.encodeUnderlying(expr, t)
+}
+
+func (g *generator) encodeUnderlying(expr string, t types.Type) error {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		c, ok := basicCodecs[u.Kind()]
+		if !ok {
+			return fmt.Errorf("unsupported type %s", t)
+		}
+		g.check(fmt.Sprintf("s.Serialize%s(%s)", c.method, convert(expr, t, c.goType)))
+	case *types.Slice:
+		if isByteSlice(u) {
+			g.check(fmt.Sprintf("s.SerializeBytes(%s)", convertBytes(expr, t)))
+			return nil
+		}
+		if isNamedByteSlice(u) {
+			g.check(fmt.Sprintf("s.SerializeValue(%s)", expr))
+			return nil
+		}
+		g.check(fmt.Sprintf("s.SerializeUint(uint(len(%s)))", expr))
+		return g.encodeElems(expr, u.Elem())
+	case *types.Array:
+		return g.encodeElems(expr, u.Elem())
+	case *types.Map:
+		g.check(fmt.Sprintf("s.SerializeUint(uint(len(%s)))", expr))
+		k, e := g.temp("k"), g.temp("e")
+		g.printf("for %s, %s := range %s {\n", k, e, expr)
+		if err := g.encode(k, u.Key()); err != nil {
+			return err
+		}
+		if err := g.encode(e, u.Elem()); err != nil {
+			return err
+		}
+		g.printf("}\n")
+	case *types.Struct:
+		if err := checkExported(u); err != nil {
+			return fmt.Errorf("%s %v", t, err)
+		}
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if !f.Exported() {
+				continue
+			}
+			wrapper, _, err := tagWrapper(u, i)
+			if err != nil {
+				return fmt.Errorf("field %s: %v", f.Name(), err)
+			}
+			if wrapper != "" {
+				g.check(fmt.Sprintf("%s(%s.%s).MarshalPostcard(s)", wrapper, expr, f.Name()))
+				continue
+			}
+			if _, ok, err := byteArrayTag(u, i); err != nil {
+				return fmt.Errorf("field %s: %v", f.Name(), err)
+			} else if ok {
+				g.check(fmt.Sprintf("s.SerializeBytes(%s.%s[:])", expr, f.Name()))
+				continue
+			}
+			if n, ok, err := maxTag(u, i); err != nil {
+				return fmt.Errorf("field %s: %v", f.Name(), err)
+			} else if ok {
+				if _, isOption := optionElem(f.Type()); isOption {
+					g.printf("if %s.%s.Valid {\n", expr, f.Name())
+					g.check(fmt.Sprintf("postcard.CheckMax(len(%s.%s.Value), %d)", expr, f.Name(), n))
+					g.printf("}\n")
+				} else {
+					g.check(fmt.Sprintf("postcard.CheckMax(len(%s.%s), %d)", expr, f.Name(), n))
+				}
+			}
+			if err := g.encode(expr+"."+f.Name(), f.Type()); err != nil {
+				return fmt.Errorf("field %s: %v", f.Name(), err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+	}
+	return nil
+}
+
+// encodeElems encodes each element of the slice or array expr. Elements
+// that encode in nothing, such as postcard.Unit, need no loop.
+func (g *generator) encodeElems(expr string, elem types.Type) error {
+	i := g.temp("i")
+	start := g.buf.Len()
+	g.printf("for %s := range %s {\n", i, expr)
+	body := g.buf.Len()
+	if err := g.encode(expr+"["+i+"]", elem); err != nil {
+		return err
+	}
+	if g.buf.Len() == body {
+		g.buf.Truncate(start)
+		return nil
+	}
+	g.printf("}\n")
+	return nil
+}
+
+// checkExported rejects structs that have fields but none exported: they
+// would encode as nothing, which the runtime reports as an error too.
+func checkExported(s *types.Struct) error {
+	if s.NumFields() == 0 {
+		return nil
+	}
+	for i := 0; i < s.NumFields(); i++ {
+		if s.Field(i).Exported() {
+			return nil
+		}
+	}
+	return fmt.Errorf("has no exported fields to encode")
+}
+
+func convertBytes(expr string, t types.Type) string {
+	return convert(expr, t, "[]byte")
+}
+
+func (g *generator) decode(expr string, t types.Type) error {
+	switch {
+	case isVarint(t):
+		x := g.temp("x")
+		g.printf("%s, err := d.DeserializeVarint()\nif err != nil {\nreturn err\n}\n", x)
+		g.printf("%s = %s\n", expr, x)
+		return nil
+	case timeWrapper(t) != "":
+		x := g.temp("x")
+		g.printf("var %s %s\n", x, timeWrapper(t))
+		g.check(x + ".UnmarshalPostcard(d)")
+		g.printf("%s = %s(%s)\n", expr, g.typeString(t), x)
+		return nil
+	case netipCodec(t) != "":
+		x := g.temp("x")
+		g.printf("%s, err := d.Deserialize%s()\nif err != nil {\nreturn err\n}\n", x, netipCodec(t))
+		g.printf("%s = %s\n", expr, x)
+		return nil
+	case g.hasMethod(t, "UnmarshalPostcard"):
+		g.check(expr + ".UnmarshalPostcard(d)")
+		return nil
+	}
+	return g.decodeUnderlying(expr, t)
+}
+
+func (g *generator) decodeUnderlying(expr string, t types.Type) error {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		c, ok := basicCodecs[u.Kind()]
+		if !ok {
+			return fmt.Errorf("unsupported type %s", t)
+		}
+		x := g.temp("x")
+		g.printf("%s, err := d.Deserialize%s()\nif err != nil {\nreturn err\n}\n", x, c.method)
+		g.printf("%s = %s\n", expr, g.convertTo(x, t))
+	case *types.Slice:
+		if isByteSlice(u) {
+			b := g.temp("b")
+			g.printf("%s, err := d.DeserializeBytes()\nif err != nil {\nreturn err\n}\n", b)
+			g.printf("%s = %s\n", expr, g.convertTo(b, t))
+			return nil
+		}
+		if isNamedByteSlice(u) {
+			g.check(fmt.Sprintf("d.DeserializeValue(&%s)", expr))
+			return nil
+		}
+		n := g.temp("n")
+		g.printf("%s, err := d.DeserializeUint()\nif err != nil {\nreturn err\n}\n", n)
+		return g.decodeElems(expr, t, u, n)
+	case *types.Array:
+		i := g.temp("i")
+		g.printf("for %s := range %s {\n", i, expr)
+		if err := g.decode(expr+"["+i+"]", u.Elem()); err != nil {
+			return err
+		}
+		g.printf("}\n")
+	case *types.Map:
+		n := g.temp("n")
+		g.printf("%s, err := d.DeserializeUint()\nif err != nil {\nreturn err\n}\n", n)
+		return g.decodeEntries(expr, t, u, n)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if !f.Exported() {
+				continue
+			}
+			wrapper, _, err := tagWrapper(u, i)
+			if err != nil {
+				return fmt.Errorf("field %s: %v", f.Name(), err)
+			}
+			if wrapper != "" {
+				x := g.temp("x")
+				g.printf("{\nvar %s %s\n", x, wrapper)
+				g.check(x + ".UnmarshalPostcard(d)")
+				g.printf("%s.%s = %s(%s)\n}\n", expr, f.Name(), g.typeString(f.Type()), x)
+				continue
+			}
+			if _, ok, _ := byteArrayTag(u, i); ok {
+				g.check(fmt.Sprintf("d.DeserializeByteArray(%s.%s[:])", expr, f.Name()))
+				continue
+			}
+			g.printf("{\n")
+			if n, ok, _ := maxTag(u, i); ok {
+				err = g.decodeBounded(expr+"."+f.Name(), f.Type(), n)
+			} else {
+				err = g.decode(expr+"."+f.Name(), f.Type())
+			}
+			if err != nil {
+				return fmt.Errorf("field %s: %v", f.Name(), err)
+			}
+			g.printf("}\n")
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+	}
+	return nil
+}
+
+// decodeElems decodes the n elements of the slice expr, of type t, once
+// their count has been read into n. The count comes from the input, so the
+// slice only preallocates what the rest of the input could hold and grows
+// past that, as the reflective path does.
+func (g *generator) decodeElems(expr string, t types.Type, u *types.Slice, n string) error {
+	i, e := g.temp("i"), g.temp("e")
+	g.check(fmt.Sprintf("d.CheckLen(%s, %d)", n, g.minSize(u.Elem())))
+	g.printf("%s = make(%s, 0, d.PreallocLen(%s))\n", expr, g.typeString(t), n)
+	g.printf("for %s := uint(0); %s < %s; %s++ {\n", i, i, n, i)
+	g.printf("var %s %s\n", e, g.typeString(u.Elem()))
+	if err := g.decode(e, u.Elem()); err != nil {
+		return err
+	}
+	g.printf("%s = append(%s, %s)\n}\n", expr, expr, e)
+	return nil
+}
+
+// minSize mirrors the runtime's lower bound on the encoded size of t, which
+// decoders hold length prefixes against with Deserializer.CheckLen.
+func (g *generator) minSize(t types.Type) int {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == postcardPath {
+		switch named.Obj().Name() {
+		case "Option", "Result":
+			return 1
+		}
+	}
+	// Types generated alongside are bounded by their fields.
+	if named, ok := t.(*types.Named); ok && !g.pkg.IsMarked(named.Obj()) && g.hasMethod(t, "UnmarshalPostcard") {
+		return 0
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		return 0
+	case *types.Array:
+		return int(u.Len()) * g.minSize(u.Elem())
+	case *types.Struct:
+		n := 0
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); f.Exported() {
+				n += g.minSize(f.Type())
+			}
+		}
+		return n
+	}
+	return 1
+}
+
+// decodeEntries decodes the n entries of the map expr, of type t, once
+// their count has been read into n.
+func (g *generator) decodeEntries(expr string, t types.Type, u *types.Map, n string) error {
+	i, k, e := g.temp("i"), g.temp("k"), g.temp("e")
+	g.check(fmt.Sprintf("d.CheckLen(%s, %d)", n, g.minSize(u.Key())+g.minSize(u.Elem())))
+	g.printf("if %s == nil {\n%s = make(%s)\n}\n", expr, expr, g.typeString(t))
+	g.printf("for %s := uint(0); %s < %s; %s++ {\n", i, i, n, i)
+	g.printf("var %s %s\n", k, g.typeString(u.Key()))
+	if err := g.decode(k, u.Key()); err != nil {
+		return err
+	}
+	g.printf("var %s %s\n", e, g.typeString(u.Elem()))
+	if err := g.decode(e, u.Elem()); err != nil {
+		return err
+	}
+	g.printf("%s[%s] = %s\n}\n", expr, k, e)
+	return nil
+}
+
+// decodeBounded decodes expr, of type t, a slice, string or map of at most
+// max elements, failing on a longer length before anything is allocated.
+// Options and types with their own decoding go through the runtime, which
+// bounds them the same way.
+func (g *generator) decodeBounded(expr string, t types.Type, max int) error {
+	if _, isOption := optionElem(t); isOption || g.hasMethod(t, "UnmarshalPostcard") {
+		g.check(fmt.Sprintf("d.DeserializeBounded(&%s, %d)", expr, max))
+		return nil
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		x := g.temp("x")
+		g.printf("%s, err := d.DeserializeBoundedString(%d)\nif err != nil {\nreturn err\n}\n", x, max)
+		g.printf("%s = %s\n", expr, g.convertTo(x, t))
+	case *types.Slice:
+		switch {
+		case isByteSlice(u):
+			x := g.temp("x")
+			g.printf("%s, err := d.DeserializeBoundedBytes(%d)\nif err != nil {\nreturn err\n}\n", x, max)
+			g.printf("%s = %s\n", expr, g.convertTo(x, t))
+		case isNamedByteSlice(u):
+			g.check(fmt.Sprintf("d.DeserializeBounded(&%s, %d)", expr, max))
+		default:
+			n := g.temp("n")
+			g.printf("%s, err := d.DeserializeBoundedLen(%d)\nif err != nil {\nreturn err\n}\n", n, max)
+			return g.decodeElems(expr, t, u, n)
+		}
+	case *types.Map:
+		n := g.temp("n")
+		g.printf("%s, err := d.DeserializeBoundedLen(%d)\nif err != nil {\nreturn err\n}\n", n, max)
+		return g.decodeEntries(expr, t, u, n)
+	}
+	return nil
+}
+
+// convertTo converts x to t when t is a defined type.
+func (g *generator) convertTo(x string, t types.Type) string {
+	if _, ok := t.(*types.Named); !ok {
+		return x
+	}
+	return g.typeString(t) + "(" + x + ")"
+}
+
+func (g *generator) size(expr string, t types.Type) {
+	switch {
+	case isVarint(t):
+		g.printf("n += postcard.SizeOfUint(uint64(%s))\n", expr)
+		return
+	case timeWrapper(t) != "":
+		g.printf("n += %s(%s).SizePostcard()\n", timeWrapper(t), expr)
+		return
+	case netipCodec(t) != "":
+		g.printf("n += postcard.SizeOf(%s)\n", expr)
+		return
+	case g.hasMethod(t, "SizePostcard"):
+		g.printf("n += %s.SizePostcard()\n", expr)
+		return
+	case g.hasMethod(t, "MarshalPostcard"):
+		g.printf("n += postcard.SizeOf(%s)\n", expr)
+		return
+	}
+	g.sizeUnderlying(expr, t)
+}
+
+func (g *generator) sizeUnderlying(expr string, t types.Type) {
+	if n, ok := g.fixedSize(t); ok {
+		if n > 0 {
+			g.printf("n += %d\n", n)
+		}
+		return
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		c := basicCodecs[u.Kind()]
+		if u.Kind() == types.String {
+			expr = convert(expr, t, c.goType)
+		}
+		g.printf("n += "+c.size+"\n", expr)
+	case *types.Slice:
+		if isByteSlice(u) {
+			g.printf("n += postcard.SizeOfBytes(%s)\n", convertBytes(expr, t))
+			return
+		}
+		if isNamedByteSlice(u) {
+			g.printf("n += postcard.SizeOf(%s)\n", expr)
+			return
+		}
+		g.printf("n += postcard.SizeOfUint(uint64(len(%s)))\n", expr)
+		if sz, ok := g.fixedSize(u.Elem()); ok {
+			if sz > 0 {
+				g.printf("n += %d * len(%s)\n", sz, expr)
+			}
+			return
+		}
+		i := g.temp("i")
+		g.printf("for %s := range %s {\n", i, expr)
+		g.size(expr+"["+i+"]", u.Elem())
+		g.printf("}\n")
+	case *types.Array:
+		i := g.temp("i")
+		g.printf("for %s := range %s {\n", i, expr)
+		g.size(expr+"["+i+"]", u.Elem())
+		g.printf("}\n")
+	case *types.Map:
+		g.printf("n += postcard.SizeOfUint(uint64(len(%s)))\n", expr)
+		keySize, keyFixed := g.fixedSize(u.Key())
+		elemSize, elemFixed := g.fixedSize(u.Elem())
+		if keyFixed && elemFixed {
+			if keySize+elemSize > 0 {
+				g.printf("n += %d * len(%s)\n", keySize+elemSize, expr)
+			}
+			return
+		}
+		// Fixed-size keys or values are counted without their variable.
+		k, e := "_", "_"
+		if !keyFixed {
+			k = g.temp("k")
+		}
+		if !elemFixed {
+			e = g.temp("e")
+		}
+		g.printf("for %s, %s := range %s {\n", k, e, expr)
+		if keyFixed {
+			g.printf("n += %d\n", keySize)
+		} else {
+			g.size(k, u.Key())
+		}
+		if elemFixed {
+			g.printf("n += %d\n", elemSize)
+		} else {
+			g.size(e, u.Elem())
+		}
+		g.printf("}\n")
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if !f.Exported() {
+				continue
+			}
+			if wrapper, n, _ := tagWrapper(u, i); n > 0 {
+				g.printf("n += %d\n", n)
+				continue
+			} else if wrapper != "" {
+				g.printf("n += %s(%s.%s).SizePostcard()\n", wrapper, expr, f.Name())
+				continue
+			}
+			if n, ok, _ := byteArrayTag(u, i); ok {
+				g.printf("n += %d\n", byteArraySize(n))
+				continue
+			}
+			g.size(expr+"."+f.Name(), f.Type())
+		}
+	}
+}
+
+// fixedSize reports the encoded size of t if it does not depend on the value.
+func (g *generator) fixedSize(t types.Type) (int, bool) {
+	if isVarint(t) || timeWrapper(t) != "" || netipCodec(t) != "" || g.hasMethod(t, "MarshalPostcard") {
+		return 0, false
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch u.Kind() {
+		case types.Bool, types.Int8, types.Uint8:
+			return 1, true
+		case types.Float32:
+			return 4, true
+		case types.Float64, types.Complex64:
+			return 8, true
+		case types.Complex128:
+			return 16, true
+		}
+	case *types.Array:
+		if n, ok := g.fixedSize(u.Elem()); ok {
+			return n * int(u.Len()), true
+		}
+	case *types.Struct:
+		total := 0
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if !f.Exported() {
+				continue
+			}
+			if wrapper, n, _ := tagWrapper(u, i); n > 0 {
+				total += n
+				continue
+			} else if wrapper != "" {
+				return 0, false
+			}
+			if n, ok, _ := byteArrayTag(u, i); ok {
+				total += byteArraySize(n)
+				continue
+			}
+			n, ok := g.fixedSize(f.Type())
+			if !ok {
+				return 0, false
+			}
+			total += n
+		}
+		return total, true
+	}
+	return 0, false
+}
@@ -0,0 +1,61 @@
+// Command postcard-gen writes reflection-free postcard encoders for the types
+// of a package that are marked with the //postcard:generate directive.
+//
+// Typical use is a go:generate line in the package:
+//
+//	//go:generate go run github.com/yixinin/postcard-go/cmd/postcard-gen
+//
+// For every marked type it emits MarshalPostcard, UnmarshalPostcard and
+// SizePostcard methods into postcard_gen.go, and a test into
+// postcard_gen_test.go asserting the output is byte-identical to
+// postcard.Serialize.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yixinin/postcard-go/internal/load"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "package directory")
+	output := flag.String("output", "postcard_gen.go", "output file name, relative to -dir")
+	tests := flag.Bool("tests", true, "also write a _test.go file comparing against the reflective encoder")
+	flag.Parse()
+
+	if err := run(*dir, *output, *tests); err != nil {
+		fmt.Fprintln(os.Stderr, "postcard-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, output string, tests bool) error {
+	pkg, err := load.Dir(dir, output)
+	if err != nil {
+		return err
+	}
+	if len(pkg.Marked) == 0 {
+		return fmt.Errorf("no types marked with %s in %s", load.Directive, dir)
+	}
+
+	src, err := Generate(pkg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, output), src, 0o644); err != nil {
+		return err
+	}
+
+	if !tests {
+		return nil
+	}
+	testSrc, err := GenerateTest(pkg)
+	if err != nil {
+		return err
+	}
+	testName := output[:len(output)-len(filepath.Ext(output))] + "_test.go"
+	return os.WriteFile(filepath.Join(dir, testName), testSrc, 0o644)
+}
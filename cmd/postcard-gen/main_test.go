@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yixinin/postcard-go/internal/load"
+)
+
+const gentestDir = "../../internal/gentest"
+
+// TestGoldenGentest keeps the checked-in output in internal/gentest current.
+func TestGoldenGentest(t *testing.T) {
+	pkg, err := load.Dir(gentestDir, "postcard_gen.go")
+	if err != nil {
+		t.Fatalf("load.Dir error = %v", err)
+	}
+
+	tests := []struct {
+		file string
+		gen  func(*load.Package) ([]byte, error)
+	}{
+		{"postcard_gen.go", Generate},
+		{"postcard_gen_test.go", GenerateTest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := tt.gen(pkg)
+			if err != nil {
+				t.Fatalf("generate error = %v", err)
+			}
+			want, err := os.ReadFile(filepath.Join(gentestDir, tt.file))
+			if err != nil {
+				t.Fatalf("ReadFile error = %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s is stale; run go generate in internal/gentest", tt.file)
+			}
+		})
+	}
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	src := `package bad
+
+//postcard:generate
+type Node struct {
+	Next *Node
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "bad.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := load.Dir(dir)
+	if err != nil {
+		t.Fatalf("load.Dir error = %v", err)
+	}
+	_, err = Generate(pkg)
+	if err == nil || !strings.Contains(err.Error(), "field Next") {
+		t.Errorf("Generate error = %v, want unsupported field Next", err)
+	}
+}
+
+func TestGenerateBadTag(t *testing.T) {
+	dir := t.TempDir()
+	src := `package bad
+
+//postcard:generate
+type Reg struct {
+	Addr uint32 ` + "`postcard:\"fixint=le,char\"`" + `
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "bad.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := load.Dir(dir)
+	if err != nil {
+		t.Fatalf("load.Dir error = %v", err)
+	}
+	_, err = Generate(pkg)
+	if err == nil || !strings.Contains(err.Error(), "field Addr: fixint and char cannot be combined") {
+		t.Errorf("Generate error = %v, want the conflicting tag rejected", err)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+
+	"github.com/yixinin/postcard-go/internal/load"
+)
+
+// GenerateTest returns a test file that fills every marked type with random
+// values and checks the generated methods against the reflective encoder.
+func GenerateTest(pkg *load.Package) ([]byte, error) {
+	var body bytes.Buffer
+	needBytes := false
+	for _, obj := range pkg.Marked {
+		ordered := !hasMap(obj.Type(), map[types.Type]bool{})
+		needBytes = needBytes || ordered
+		writeTypeTest(&body, obj.Name(), ordered)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(header)
+	fmt.Fprintf(&out, "package %s\n\n", pkg.Types.Name())
+	out.WriteString("import (\n")
+	if needBytes {
+		out.WriteString("\"bytes\"\n")
+	}
+	out.WriteString("\"math/rand\"\n\"net/netip\"\n\"reflect\"\n\"strings\"\n\"testing\"\n\"testing/quick\"\n\"time\"\n\n")
+	fmt.Fprintf(&out, "%q\n)\n\n", postcardPath)
+	out.Write(body.Bytes())
+	out.WriteString(fillHelper)
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated test: %v", err)
+	}
+	return src, nil
+}
+
+// writeTypeTest emits the test for one type. Map iteration order is random,
+// so for types containing maps only the lengths of the encodings are
+// compared; decoding still has to agree.
+func writeTypeTest(w *bytes.Buffer, name string, ordered bool) {
+	fmt.Fprintf(w, "func Test%sPostcard(t *testing.T) {\n", name)
+	fmt.Fprintf(w, "type plain %s\n", name)
+	w.WriteString("r := rand.New(rand.NewSource(1))\n")
+	w.WriteString("for i := 0; i < 100; i++ {\n")
+	fmt.Fprintf(w, "var v %s\n", name)
+	w.WriteString("postcardGenFill(reflect.ValueOf(&v).Elem(), r)\n\n")
+	w.WriteString(`s := postcard.NewSerializer(nil)
+if err := v.MarshalPostcard(s); err != nil {
+	t.Fatalf("MarshalPostcard(%+v) error = %v", v, err)
+}
+got, _ := s.Result()
+want, err := postcard.Serialize(plain(v))
+if err != nil {
+	t.Fatalf("Serialize(%+v) error = %v", v, err)
+}
+`)
+	if ordered {
+		w.WriteString(`if !bytes.Equal(got, want) {
+	t.Fatalf("MarshalPostcard(%+v) = %v, want %v", v, got, want)
+}
+`)
+	} else {
+		w.WriteString(`if len(got) != len(want) {
+	t.Fatalf("MarshalPostcard(%+v) = %v, want %v", v, got, want)
+}
+`)
+	}
+	w.WriteString(`if n := v.SizePostcard(); n != len(got) {
+	t.Fatalf("SizePostcard(%+v) = %d, want %d", v, n, len(got))
+}
+
+`)
+	fmt.Fprintf(w, "var decoded %s\n", name)
+	w.WriteString(`if err := decoded.UnmarshalPostcard(postcard.NewDeserializer(got)); err != nil {
+	t.Fatalf("UnmarshalPostcard(%v) error = %v", got, err)
+}
+var ref plain
+if err := postcard.Deserialize(got, &ref); err != nil {
+	t.Fatalf("Deserialize(%v) error = %v", got, err)
+}
+`)
+	fmt.Fprintf(w, "if !reflect.DeepEqual(decoded, %s(ref)) {\n", name)
+	w.WriteString(`t.Fatalf("UnmarshalPostcard(%v) = %+v, want %+v", got, decoded, ref)
+}
+}
+}
+
+`)
+}
+
+const fillHelper = `// postcardGenFill sets the exported parts of v to random values.
+func postcardGenFill(v reflect.Value, r *rand.Rand) {
+	switch v.Type() {
+	case reflect.TypeOf(postcard.Char(0)):
+		postcardGenRune(v, r)
+		return
+	case reflect.TypeOf(time.Duration(0)), reflect.TypeOf(postcard.Duration(0)):
+		v.SetInt(r.Int63())
+		return
+	case reflect.TypeOf(time.Time{}):
+		v.Set(reflect.ValueOf(time.Unix(r.Int63n(1<<33), 0).UTC()))
+		return
+	case reflect.TypeOf(netip.Addr{}):
+		v.Set(reflect.ValueOf(postcardGenAddr(r)))
+		return
+	case reflect.TypeOf(netip.AddrPort{}):
+		v.Set(reflect.ValueOf(netip.AddrPortFrom(postcardGenAddr(r), uint16(r.Intn(1<<16)))))
+		return
+	case reflect.TypeOf(netip.Prefix{}):
+		a := postcardGenAddr(r)
+		v.Set(reflect.ValueOf(netip.PrefixFrom(a, r.Intn(a.BitLen()+1))))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			switch {
+			case !f.IsExported():
+			case strings.Contains(f.Tag.Get("postcard"), "char"):
+				postcardGenRune(v.Field(i), r)
+			default:
+				postcardGenFill(v.Field(i), r)
+			}
+		}
+	case reflect.Slice:
+		n := r.Intn(4)
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			postcardGenFill(v.Index(i), r)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			postcardGenFill(v.Index(i), r)
+		}
+	case reflect.Map:
+		n := r.Intn(4)
+		v.Set(reflect.MakeMapWithSize(v.Type(), n))
+		for i := 0; i < n; i++ {
+			k := reflect.New(v.Type().Key()).Elem()
+			postcardGenFill(k, r)
+			e := reflect.New(v.Type().Elem()).Elem()
+			postcardGenFill(e, r)
+			v.SetMapIndex(k, e)
+		}
+	default:
+		if x, ok := quick.Value(v.Type(), r); ok {
+			v.Set(x)
+		}
+	}
+}
+
+// postcardGenAddr returns a random IPv4 or IPv6 address.
+func postcardGenAddr(r *rand.Rand) netip.Addr {
+	var b [16]byte
+	r.Read(b[:])
+	if r.Intn(2) == 0 {
+		return netip.AddrFrom4([4]byte(b[:4]))
+	}
+	return netip.AddrFrom16(b)
+}
+
+// postcardGenRune sets v to a random Unicode scalar value.
+func postcardGenRune(v reflect.Value, r *rand.Rand) {
+	c := r.Int63n(0x10FFFF - 0x800)
+	if c >= 0xD800 {
+		c += 0x800
+	}
+	v.SetInt(c)
+}
+`
+
+func hasMap(t types.Type, seen map[types.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch u := t.Underlying().(type) {
+	case *types.Map:
+		return true
+	case *types.Slice:
+		return hasMap(u.Elem(), seen)
+	case *types.Array:
+		return hasMap(u.Elem(), seen)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if u.Field(i).Exported() && hasMap(u.Field(i).Type(), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var primitives = map[string]string{
+	"bool":  "bool",
+	"u8":    "uint8",
+	"u16":   "uint16",
+	"u32":   "uint32",
+	"u64":   "uint64",
+	"usize": "uint",
+	"i8":    "int8",
+	"i16":   "int16",
+	"i32":   "int32",
+	"i64":   "int64",
+	"isize": "int",
+	"f32":   "float32",
+	"f64":   "float64",
+	"str":   "string",
+}
+
+var fixintPrimitives = map[string]bool{
+	"u16": true, "u32": true, "u64": true,
+	"i16": true, "i32": true, "i64": true,
+}
+
+// transparent are smart pointers serde encodes as their contents.
+var transparent = map[string]bool{"Box": true, "Rc": true, "Arc": true, "Cow": true}
+
+var mapTypes = map[string]bool{
+	"BTreeMap": true, "HashMap": true, "IndexMap": true,
+	"FnvIndexMap": true, "LinearMap": true,
+}
+
+// netipTypes maps the Rust address types that encode like a netip type.
+var netipTypes = map[string]string{
+	"IpAddr":     "netip.Addr",
+	"SocketAddr": "netip.AddrPort",
+	"IpNet":      "netip.Prefix",
+}
+
+var initialisms = map[string]bool{
+	"api": true, "crc": true, "cpu": true, "id": true, "ip": true,
+	"rpc": true, "tcp": true, "udp": true, "uri": true, "url": true,
+	"usb": true, "utf8": true, "uuid": true,
+}
+
+type goGen struct {
+	pkg      string
+	items    map[string]*item
+	buf      bytes.Buffer
+	enums    []*item
+	postcard bool
+	netip    bool
+}
+
+// Generate returns a Go source file declaring equivalents of items.
+func Generate(pkg, source string, items []*item) ([]byte, error) {
+	g := &goGen{pkg: pkg, items: map[string]*item{}}
+	for _, it := range items {
+		if _, dup := g.items[it.name]; dup {
+			return nil, fmt.Errorf("%s declared twice", it.name)
+		}
+		g.items[it.name] = it
+	}
+	for _, it := range items {
+		if it.kind != itemEnum || isCLike(it) {
+			continue
+		}
+		for _, v := range it.variants {
+			if _, dup := g.items[it.name+v.name]; dup {
+				return nil, fmt.Errorf("variant type %s%s collides with a declared type", it.name, v.name)
+			}
+		}
+	}
+
+	for _, it := range items {
+		if err := g.item(it); err != nil {
+			return nil, fmt.Errorf("%s: %v", it.name, err)
+		}
+	}
+	if len(g.enums) > 0 {
+		g.postcard = true
+		g.buf.WriteString("func init() {\n")
+		for _, it := range g.enums {
+			names := make([]string, len(it.variants))
+			for i, v := range it.variants {
+				names[i] = it.name + v.name + "{}"
+			}
+			fmt.Fprintf(&g.buf, "postcard.RegisterEnum[%s](%s)\n", it.name, strings.Join(names, ", "))
+		}
+		g.buf.WriteString("}\n")
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by postcard-rs2go from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&out, "package %s\n\n", g.pkg)
+	switch {
+	case g.netip && g.postcard:
+		out.WriteString("import (\n\"net/netip\"\n\n\"github.com/yixinin/postcard-go/postcard\"\n)\n\n")
+	case g.netip:
+		out.WriteString("import \"net/netip\"\n\n")
+	case g.postcard:
+		out.WriteString("import \"github.com/yixinin/postcard-go/postcard\"\n\n")
+	}
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+func (g *goGen) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func isCLike(it *item) bool {
+	for _, v := range it.variants {
+		if v.kind != variantUnit {
+			return false
+		}
+	}
+	return len(it.variants) > 0
+}
+
+func (g *goGen) item(it *item) error {
+	switch it.kind {
+	case itemUnitStruct:
+		g.printf("type %s struct{}\n\n", it.name)
+	case itemStruct:
+		body, err := g.structBody(it.fields)
+		if err != nil {
+			return err
+		}
+		g.printf("type %s %s\n\n", it.name, body)
+	case itemTupleStruct:
+		if len(it.fields) == 1 {
+			ty, _, err := g.goType(it.fields[0].ty)
+			if err != nil {
+				return err
+			}
+			if it.fields[0].fixint == "" && !it.fields[0].bytes && g.definable(it.fields[0].ty) {
+				g.printf("type %s %s\n\n", it.name, ty)
+			} else {
+				body, err := g.structBody(it.fields)
+				if err != nil {
+					return err
+				}
+				g.printf("type %s %s\n\n", it.name, body)
+			}
+			g.schema(it.name, fmt.Sprintf("postcard.NewtypeStructSchema[%s](%q)", ty, it.name))
+			return nil
+		}
+		body, err := g.structBody(it.fields)
+		if err != nil {
+			return err
+		}
+		g.printf("type %s %s\n\n", it.name, body)
+		if len(it.fields) > 1 {
+			g.schema(it.name, fmt.Sprintf("postcard.TupleStructSchema[%s](%q)", it.name, it.name))
+		}
+	case itemAlias:
+		ty, _, err := g.goType(it.alias)
+		if err != nil {
+			return err
+		}
+		g.printf("type %s = %s\n\n", it.name, ty)
+	case itemEnum:
+		if isCLike(it) {
+			// serde encodes unit variants as their varint u32 index.
+			g.printf("type %s uint32\n\nconst (\n", it.name)
+			for i, v := range it.variants {
+				if i == 0 {
+					g.printf("%s%s %s = iota\n", it.name, v.name, it.name)
+				} else {
+					g.printf("%s%s\n", it.name, v.name)
+				}
+			}
+			g.printf(")\n\n")
+			names := make([]string, len(it.variants))
+			for i, v := range it.variants {
+				names[i] = strconv.Quote(v.name)
+			}
+			g.schema(it.name, fmt.Sprintf("postcard.UnitEnumSchema(%q, %s), nil", it.name, strings.Join(names, ", ")))
+			return nil
+		}
+		return g.enum(it)
+	}
+	return nil
+}
+
+// schema writes a PostcardSchema method on the Go type name, which Rust
+// describes differently from its reflection, returning describe.
+func (g *goGen) schema(name, describe string) {
+	g.postcard = true
+	g.printf("func (%s) PostcardSchema() (*postcard.Schema, error) {\nreturn %s\n}\n\n", name, describe)
+}
+
+// definable reports whether a newtype over t can be a Go defined type
+// without losing the encoding. Options, results, enums, the postcard types
+// for char, u128, i128, Duration and UUID and the netip types keep their
+// behaviour only through methods, registration or their exact type, none of
+// which a defined type inherits.
+func (g *goGen) definable(t *rustType) bool {
+	for t.kind == typePath && transparent[t.name] && len(t.args) == 1 {
+		t = t.args[0]
+	}
+	if t.kind != typePath {
+		return t.kind != typeTuple
+	}
+	switch t.name {
+	case "Option", "Result", "char", "u128", "i128", "Duration", "Uuid", "IpAddr", "SocketAddr", "IpNet":
+		return false
+	}
+	if it, ok := g.items[t.name]; ok {
+		switch it.kind {
+		case itemEnum:
+			return isCLike(it)
+		case itemAlias:
+			return g.definable(it.alias)
+		}
+	}
+	return true
+}
+
+func (g *goGen) enum(it *item) error {
+	marker := "is" + it.name
+	g.printf("type %s interface {\n%s()\n}\n\n", it.name, marker)
+	for _, v := range it.variants {
+		name := it.name + v.name
+		switch v.kind {
+		case variantUnit:
+			g.printf("type %s struct{}\n\n", name)
+		case variantNewtype:
+			f := v.fields[0]
+			f.name = "value"
+			body, err := g.structBody([]field{f})
+			if err != nil {
+				return fmt.Errorf("variant %s: %v", v.name, err)
+			}
+			g.printf("type %s %s\n\n", name, body)
+		default:
+			body, err := g.structBody(v.fields)
+			if err != nil {
+				return fmt.Errorf("variant %s: %v", v.name, err)
+			}
+			g.printf("type %s %s\n\n", name, body)
+		}
+	}
+	for _, v := range it.variants {
+		g.printf("func (%s%s) %s() {}\n", it.name, v.name, marker)
+	}
+	g.printf("\n")
+	for _, v := range it.variants {
+		name := it.name + v.name
+		var describe string
+		switch v.kind {
+		case variantUnit:
+			describe = fmt.Sprintf("postcard.UnitVariantSchema(%q), nil", v.name)
+		case variantNewtype:
+			ty, _, err := g.goType(v.fields[0].ty)
+			if err != nil {
+				return fmt.Errorf("variant %s: %v", v.name, err)
+			}
+			describe = fmt.Sprintf("postcard.NewtypeVariantSchema[%s](%q)", ty, v.name)
+		case variantTuple:
+			describe = fmt.Sprintf("postcard.TupleVariantSchema[%s](%q)", name, v.name)
+		default:
+			describe = fmt.Sprintf("postcard.StructVariantSchema[%s](%q)", name, v.name)
+		}
+		g.printf("func (%s) PostcardVariant() (postcard.SchemaVariant, error) {\nreturn %s\n}\n\n", name, describe)
+	}
+	g.enums = append(g.enums, it)
+	return nil
+}
+
+// structBody renders fields as a Go struct type. Unnamed (tuple) fields are
+// called Field0, Field1, ...
+func (g *goGen) structBody(fields []field) (string, error) {
+	if len(fields) == 0 {
+		return "struct{}", nil
+	}
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for i, f := range fields {
+		name := fmt.Sprintf("Field%d", i)
+		if f.name != "" {
+			name = goName(f.name)
+		}
+		ty, tag, err := g.goType(f.ty)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %v", name, err)
+		}
+		if f.fixint != "" {
+			if f.ty.kind != typePath || !fixintPrimitives[g.resolve(f.ty).name] {
+				return "", fmt.Errorf("field %s: fixint needs a 16, 32 or 64-bit integer, got %s", name, f.ty)
+			}
+			tag = append(tag, "fixint="+f.fixint)
+		}
+		if f.bytes {
+			switch elem := g.resolve(f.ty); {
+			case elem.kind == typeArray && g.resolve(elem.args[0]).name == "u8":
+				tag = append(tag, "bytes")
+			case elem.kind == typePath && elem.name == "Vec" && len(elem.args) == 1 && g.resolve(elem.args[0]).name == "u8":
+			default:
+				return "", fmt.Errorf("field %s: serde_bytes needs a byte array or Vec<u8>, got %s", name, f.ty)
+			}
+		}
+		fmt.Fprintf(&b, "%s %s", name, ty)
+		if len(tag) > 0 {
+			fmt.Fprintf(&b, " `postcard:\"%s\"`", strings.Join(tag, ","))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// resolve follows type aliases declared in the parsed sources.
+func (g *goGen) resolve(t *rustType) *rustType {
+	for t.kind == typePath {
+		it, ok := g.items[t.name]
+		if !ok || it.kind != itemAlias {
+			break
+		}
+		t = it.alias
+	}
+	return t
+}
+
+// goType maps a Rust type to Go. The returned tag options carry capacity
+// bounds of heapless collections.
+func (g *goGen) goType(t *rustType) (string, []string, error) {
+	switch t.kind {
+	case typeArray:
+		if _, err := strconv.ParseUint(t.consts[0], 0, 64); err != nil {
+			return "", nil, fmt.Errorf("array length %s must be a literal", t.consts[0])
+		}
+		elem, _, err := g.goType(t.args[0])
+		if err != nil {
+			return "", nil, err
+		}
+		return "[" + t.consts[0] + "]" + elem, nil, nil
+	case typeSlice:
+		elem, _, err := g.goType(t.args[0])
+		if err != nil {
+			return "", nil, err
+		}
+		return sliceOf(elem), nil, nil
+	case typeTuple:
+		if len(t.elems) == 0 {
+			g.postcard = true
+			return "postcard.Unit", nil, nil
+		}
+		if len(t.elems) < 2 || len(t.elems) > 8 {
+			return "", nil, fmt.Errorf("tuple %s is not supported", t)
+		}
+		elems := make([]string, len(t.elems))
+		for i, e := range t.elems {
+			elem, _, err := g.goType(e)
+			if err != nil {
+				return "", nil, err
+			}
+			elems[i] = elem
+		}
+		g.postcard = true
+		return fmt.Sprintf("postcard.Tuple%d[%s]", len(elems), strings.Join(elems, ", ")), nil, nil
+	}
+
+	var bound []string
+	if len(t.consts) == 1 {
+		bound = []string{"max=" + t.consts[0]}
+	}
+
+	if prim, ok := primitives[t.name]; ok {
+		return prim, nil, nil
+	}
+	switch {
+	case t.name == "char":
+		g.postcard = true
+		return "postcard.Char", nil, nil
+	case t.name == "Uuid" && len(t.args) == 0 && g.items[t.name] == nil:
+		g.postcard = true
+		return "postcard.UUID", nil, nil
+	case netipTypes[t.name] != "" && len(t.args) == 0 && g.items[t.name] == nil:
+		g.netip = true
+		return netipTypes[t.name], nil, nil
+	case t.name == "Ipv4Addr" && len(t.args) == 0 && g.items[t.name] == nil:
+		return "[4]byte", nil, nil
+	case t.name == "Ipv6Addr" && len(t.args) == 0 && g.items[t.name] == nil:
+		return "[16]byte", nil, nil
+	case t.name == "Complex" && len(t.args) == 1 && g.items[t.name] == nil:
+		switch t.args[0].name {
+		case "f32":
+			return "complex64", nil, nil
+		case "f64":
+			return "complex128", nil, nil
+		}
+	case t.name == "Duration" && len(t.args) == 0 && g.items[t.name] == nil:
+		g.postcard = true
+		return "postcard.Duration", nil, nil
+	case t.name == "u128":
+		g.postcard = true
+		return "postcard.Uint128", nil, nil
+	case t.name == "i128":
+		g.postcard = true
+		return "postcard.Int128", nil, nil
+	case t.name == "String":
+		return "string", bound, nil
+	case t.name == "Vec" && len(t.args) == 1:
+		elem, _, err := g.goType(t.args[0])
+		if err != nil {
+			return "", nil, err
+		}
+		return sliceOf(elem), bound, nil
+	case t.name == "Option" && len(t.args) == 1:
+		// A bound on the element stays on the field, which bounds the
+		// value inside the Option.
+		elem, elemBound, err := g.goType(t.args[0])
+		if err != nil {
+			return "", nil, err
+		}
+		g.postcard = true
+		return "postcard.Option[" + elem + "]", elemBound, nil
+	case t.name == "Result" && len(t.args) == 2:
+		ok, _, err := g.goType(t.args[0])
+		if err != nil {
+			return "", nil, err
+		}
+		e, _, err := g.goType(t.args[1])
+		if err != nil {
+			return "", nil, err
+		}
+		g.postcard = true
+		return "postcard.Result[" + ok + ", " + e + "]", nil, nil
+	case transparent[t.name] && len(t.args) == 1:
+		return g.goType(t.args[0])
+	case mapTypes[t.name] && len(t.args) == 2:
+		key, _, err := g.goType(t.args[0])
+		if err != nil {
+			return "", nil, err
+		}
+		val, _, err := g.goType(t.args[1])
+		if err != nil {
+			return "", nil, err
+		}
+		return "map[" + key + "]" + val, bound, nil
+	}
+	if _, ok := g.items[t.name]; ok && len(t.args) == 0 {
+		return t.name, nil, nil
+	}
+	return "", nil, fmt.Errorf("unsupported type %s", t)
+}
+
+func sliceOf(elem string) string {
+	if elem == "uint8" {
+		return "[]byte"
+	}
+	return "[]" + elem
+}
+
+// goName converts a snake_case Rust field name to an exported Go name.
+func goName(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(s, "_") {
+		if part == "" {
+			continue
+		}
+		if initialisms[part] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		r := []rune(part)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+// Command postcard-rs2go writes Go types equivalent to the message types
+// declared in Rust source files, so host code can follow device crates.
+//
+//	postcard-rs2go -pkg icd -o icd_gen.go messages.rs
+//
+// It understands a practical subset of Rust: structs, tuple and unit
+// structs, enums, type aliases and #[serde(with = "postcard::fixint::le")]
+// (or ::be) on integer fields and #[serde(with = "serde_bytes")] on byte
+// arrays. Other items are skipped.
+//
+// Rust types map to Go as follows:
+//
+//	u8..u64, i8..i64, usize, isize  uint8..uint64, int8..int64, uint, int
+//	String, &str, Vec<T>, [T; N]     string, string, []T, [N]T
+//	Option<T>                        postcard.Option[T]
+//	(A, B) .. (A, .., H), ()         postcard.Tuple2..Tuple8, postcard.Unit
+//	Result<T, E>                     postcard.Result[T, E]
+//	core::time::Duration             postcard.Duration
+//	num_complex::Complex<f32>, <f64> complex64, complex128
+//	IpAddr, SocketAddr, ipnet::IpNet netip.Addr, AddrPort, Prefix
+//	Ipv4Addr, Ipv6Addr               [4]byte, [16]byte
+//	uuid::Uuid                       postcard.UUID
+//	BTreeMap<K, V>, HashMap<K, V>    map[K]V
+//	Box<T>, Rc<T>, Arc<T>            T
+//	enum with only unit variants     uint32 with a constant per variant
+//	enum with data                   interface registered with
+//	                                 postcard.RegisterEnum, one struct
+//	                                 type per variant
+//
+// heapless::Vec, heapless::String and heapless maps become the plain Go type
+// with a `postcard:"max=N"` tag carrying the capacity.
+//
+// Newtype and tuple structs, enums with only unit variants and the variant
+// types of other enums get PostcardSchema or PostcardVariant methods giving
+// the Rust names and shapes, so their schemas, and so postcard-rpc keys,
+// match the Rust crate's.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	pkg := flag.String("pkg", "", "Go package name (required)")
+	output := flag.String("o", "", "output file; standard output if empty")
+	flag.Parse()
+
+	if err := run(*pkg, *output, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "postcard-rs2go:", err)
+		os.Exit(1)
+	}
+}
+
+func run(pkg, output string, files []string) error {
+	if pkg == "" {
+		return fmt.Errorf("-pkg is required")
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no Rust source files given")
+	}
+
+	var items []*item
+	var names []string
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		parsed, err := parseFile(file, string(src))
+		if err != nil {
+			return err
+		}
+		items = append(items, parsed...)
+		names = append(names, filepath.Base(file))
+	}
+
+	src, err := Generate(pkg, strings.Join(names, ", "), items)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(output, src, 0o644)
+}
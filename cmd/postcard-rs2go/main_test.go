@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGolden(t *testing.T) {
+	src, err := os.ReadFile("testdata/device.rs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := parseFile("device.rs", string(src))
+	if err != nil {
+		t.Fatalf("parseFile error = %v", err)
+	}
+	got, err := Generate("rs2gotest", "device.rs", items)
+	if err != nil {
+		t.Fatalf("Generate error = %v", err)
+	}
+
+	golden := filepath.Join("..", "..", "internal", "rs2gotest", "device.go")
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("ReadFile error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Generate() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"generic", "struct Wrap<T> { v: T }", "generic type parameters"},
+		{"tuple", "struct Wide { v: (u8, u8, u8, u8, u8, u8, u8, u8, u8) }", "is not supported"},
+		{"unknown", "struct S { v: Foreign }", "unsupported type Foreign"},
+		{"skip", "struct S { #[serde(skip)] v: u8 }", "unsupported serde attribute \"skip\""},
+		{"fixint on u8", "struct S { #[serde(with = \"postcard::fixint::le\")] v: u8 }", "fixint needs"},
+		{"serde_bytes on u32", "struct S { #[serde(with = \"serde_bytes\")] v: u32 }", "serde_bytes needs"},
+		{"const length", "struct S { v: [u8; N] }", "must be a literal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := parseFile("bad.rs", tt.src)
+			if err == nil {
+				_, err = Generate("bad", "bad.rs", items)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
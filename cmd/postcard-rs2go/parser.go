@@ -0,0 +1,591 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type typeKind int
+
+const (
+	typePath typeKind = iota
+	typeArray
+	typeSlice
+	typeTuple
+)
+
+// rustType is a parsed Rust type expression.
+type rustType struct {
+	kind typeKind
+	// name is the last segment of a path type, e.g. "Vec" for
+	// heapless::Vec<u8, 4>.
+	name string
+	// args holds the generic type arguments of a path type and the element
+	// of an array or slice.
+	args []*rustType
+	// consts holds the const generic arguments of a path type and the length
+	// of an array.
+	consts []string
+	// elems holds the members of a tuple; unit is a tuple without elements.
+	elems []*rustType
+}
+
+func (t *rustType) String() string {
+	switch t.kind {
+	case typeArray:
+		return fmt.Sprintf("[%s; %s]", t.args[0], t.consts[0])
+	case typeSlice:
+		return fmt.Sprintf("[%s]", t.args[0])
+	case typeTuple:
+		parts := make([]string, len(t.elems))
+		for i, e := range t.elems {
+			parts[i] = e.String()
+		}
+		return "(" + strings.Join(parts, ", ") + ")"
+	}
+	if len(t.args) == 0 && len(t.consts) == 0 {
+		return t.name
+	}
+	var parts []string
+	for _, a := range t.args {
+		parts = append(parts, a.String())
+	}
+	parts = append(parts, t.consts...)
+	return t.name + "<" + strings.Join(parts, ", ") + ">"
+}
+
+type field struct {
+	name   string
+	ty     *rustType
+	fixint string
+	// bytes marks #[serde(with = "serde_bytes")].
+	bytes bool
+}
+
+type variantKind int
+
+const (
+	variantUnit variantKind = iota
+	variantNewtype
+	variantTuple
+	variantStruct
+)
+
+type variant struct {
+	name   string
+	kind   variantKind
+	fields []field
+}
+
+type itemKind int
+
+const (
+	itemStruct itemKind = iota
+	itemTupleStruct
+	itemUnitStruct
+	itemEnum
+	itemAlias
+)
+
+type item struct {
+	kind     itemKind
+	name     string
+	fields   []field
+	variants []variant
+	alias    *rustType
+}
+
+type parser struct {
+	file string
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tokPunct || t.kind == tokIdent) && t.text == text
+}
+
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", p.file, p.peek().line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %q, found %s", text, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokIdent {
+		return "", p.errorf("expected identifier, found %s", t)
+	}
+	p.pos++
+	return strings.TrimPrefix(t.text, "r#"), nil
+}
+
+// parseFile returns the structs, enums and type aliases of a Rust source file
+// in source order. Other items (use, impl, fn, const, ...) are skipped.
+func parseFile(file, src string) ([]*item, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	p := &parser{file: file, toks: toks}
+
+	var items []*item
+	for p.peek().kind != tokEOF {
+		attrs, err := p.attributes()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := checkSerde(attrs); err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		p.visibility()
+		switch {
+		case p.accept("struct"):
+			it, err := p.structItem()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, it)
+		case p.accept("enum"):
+			it, err := p.enumItem()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, it)
+		case p.accept("type"):
+			it, err := p.aliasItem()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, it)
+		default:
+			if err := p.skipItem(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return items, nil
+}
+
+// attributes consumes outer and inner attributes and returns the token text
+// of each outer attribute body.
+func (p *parser) attributes() ([][]token, error) {
+	var attrs [][]token
+	for p.is("#") {
+		p.next()
+		inner := p.accept("!")
+		if err := p.expect("["); err != nil {
+			return nil, err
+		}
+		start := p.pos
+		if err := p.skipBalanced("[", "]"); err != nil {
+			return nil, err
+		}
+		if !inner {
+			attrs = append(attrs, p.toks[start:p.pos-1])
+		}
+	}
+	return attrs, nil
+}
+
+// skipBalanced consumes tokens up to and including the close that matches an
+// already consumed open.
+func (p *parser) skipBalanced(open, close string) error {
+	depth := 1
+	for depth > 0 {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			return p.errorf("unbalanced %q", open)
+		case t.kind == tokPunct && t.text == open:
+			depth++
+		case t.kind == tokPunct && t.text == close:
+			depth--
+		}
+	}
+	return nil
+}
+
+func (p *parser) visibility() {
+	if p.accept("pub") && p.is("(") {
+		p.next()
+		_ = p.skipBalanced("(", ")")
+	}
+}
+
+// skipItem consumes an item the generator does not model: everything up to a
+// top-level semicolon, or up to and including a top-level block.
+func (p *parser) skipItem() error {
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			return nil
+		case t.kind == tokPunct && t.text == ";":
+			return nil
+		case t.kind == tokPunct && t.text == "(":
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return err
+			}
+		case t.kind == tokPunct && t.text == "[":
+			if err := p.skipBalanced("[", "]"); err != nil {
+				return err
+			}
+		case t.kind == tokPunct && t.text == "{":
+			if err := p.skipBalanced("{", "}"); err != nil {
+				return err
+			}
+			p.accept(";")
+			return nil
+		}
+	}
+}
+
+// generics rejects type parameters; lifetimes are allowed and ignored.
+func (p *parser) generics() error {
+	if !p.accept("<") {
+		return nil
+	}
+	for !p.accept(">") {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			return p.errorf("unterminated generics")
+		case t.kind == tokLifetime, t.kind == tokPunct && (t.text == "," || t.text == ":" || t.text == "+"):
+		default:
+			return p.errorf("generic type parameters are not supported")
+		}
+	}
+	if p.is("where") {
+		return p.errorf("where clauses are not supported")
+	}
+	return nil
+}
+
+func (p *parser) structItem() (*item, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.generics(); err != nil {
+		return nil, err
+	}
+	it := &item{name: name}
+	switch {
+	case p.accept(";"):
+		it.kind = itemUnitStruct
+	case p.accept("("):
+		it.kind = itemTupleStruct
+		if it.fields, err = p.tupleFields(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+	case p.accept("{"):
+		it.kind = itemStruct
+		if it.fields, err = p.namedFields(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.errorf("expected struct body, found %s", p.peek())
+	}
+	return it, nil
+}
+
+// namedFields parses `name: Type, ...}` after the opening brace.
+func (p *parser) namedFields() ([]field, error) {
+	var fields []field
+	for !p.accept("}") {
+		attrs, err := p.attributes()
+		if err != nil {
+			return nil, err
+		}
+		f, err := checkSerde(attrs)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		p.visibility()
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		ty, err := p.typeExpr()
+		if err != nil {
+			return nil, err
+		}
+		f.name, f.ty = name, ty
+		fields = append(fields, f)
+		if !p.accept(",") && !p.is("}") {
+			return nil, p.errorf("expected \",\" or \"}\", found %s", p.peek())
+		}
+	}
+	return fields, nil
+}
+
+// tupleFields parses `Type, ...)` after the opening parenthesis.
+func (p *parser) tupleFields() ([]field, error) {
+	var fields []field
+	for !p.accept(")") {
+		attrs, err := p.attributes()
+		if err != nil {
+			return nil, err
+		}
+		f, err := checkSerde(attrs)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		p.visibility()
+		if f.ty, err = p.typeExpr(); err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+		if !p.accept(",") && !p.is(")") {
+			return nil, p.errorf("expected \",\" or \")\", found %s", p.peek())
+		}
+	}
+	return fields, nil
+}
+
+func (p *parser) enumItem() (*item, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.generics(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	it := &item{kind: itemEnum, name: name}
+	for !p.accept("}") {
+		attrs, err := p.attributes()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := checkSerde(attrs); err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		vname, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		v := variant{name: vname}
+		switch {
+		case p.accept("("):
+			if v.fields, err = p.tupleFields(); err != nil {
+				return nil, err
+			}
+			v.kind = variantTuple
+			if len(v.fields) == 1 {
+				v.kind = variantNewtype
+			}
+		case p.accept("{"):
+			v.kind = variantStruct
+			if v.fields, err = p.namedFields(); err != nil {
+				return nil, err
+			}
+		case p.accept("="):
+			// Explicit discriminants do not affect serde's variant index.
+			for !p.is(",") && !p.is("}") && p.peek().kind != tokEOF {
+				p.next()
+			}
+		}
+		it.variants = append(it.variants, v)
+		if !p.accept(",") && !p.is("}") {
+			return nil, p.errorf("expected \",\" or \"}\", found %s", p.peek())
+		}
+	}
+	return it, nil
+}
+
+func (p *parser) aliasItem() (*item, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.generics(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	ty, err := p.typeExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	return &item{kind: itemAlias, name: name, alias: ty}, nil
+}
+
+func (p *parser) typeExpr() (*rustType, error) {
+	switch {
+	case p.accept("&"):
+		if p.peek().kind == tokLifetime {
+			p.next()
+		}
+		p.accept("mut")
+		return p.typeExpr()
+	case p.accept("("):
+		t := &rustType{kind: typeTuple, elems: []*rustType{}}
+		for !p.accept(")") {
+			elem, err := p.typeExpr()
+			if err != nil {
+				return nil, err
+			}
+			t.elems = append(t.elems, elem)
+			if !p.accept(",") && !p.is(")") {
+				return nil, p.errorf("expected \",\" or \")\", found %s", p.peek())
+			}
+		}
+		return t, nil
+	case p.accept("["):
+		elem, err := p.typeExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.accept("]") {
+			return &rustType{kind: typeSlice, args: []*rustType{elem}}, nil
+		}
+		if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+		n := p.next()
+		if n.kind != tokNumber && n.kind != tokIdent {
+			return nil, p.errorf("expected array length, found %s", n)
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &rustType{kind: typeArray, args: []*rustType{elem}, consts: []string{n.text}}, nil
+	}
+
+	p.accept("::")
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("::") {
+		if name, err = p.ident(); err != nil {
+			return nil, err
+		}
+	}
+	t := &rustType{kind: typePath, name: name}
+	if !p.accept("<") {
+		return t, nil
+	}
+	for !p.accept(">") {
+		switch tok := p.peek(); tok.kind {
+		case tokNumber:
+			p.next()
+			t.consts = append(t.consts, tok.text)
+		case tokLifetime:
+			p.next()
+		default:
+			arg, err := p.typeExpr()
+			if err != nil {
+				return nil, err
+			}
+			t.args = append(t.args, arg)
+		}
+		if !p.accept(",") && !p.is(">") {
+			return nil, p.errorf("expected \",\" or \">\", found %s", p.peek())
+		}
+	}
+	return t, nil
+}
+
+// checkSerde validates the serde attributes in attrs and returns a field
+// carrying the encoding they request: the fixint byte order of
+// `#[serde(with = "postcard::fixint::le")]` or the bytes of
+// `#[serde(with = "serde_bytes")]`. Attributes that change the wire format
+// in ways Go cannot mirror are rejected; renames are harmless because
+// postcard does not encode names.
+func checkSerde(attrs [][]token) (field, error) {
+	var f field
+	for _, attr := range attrs {
+		if len(attr) < 2 || attr[0].text != "serde" || attr[1].text != "(" {
+			continue
+		}
+		for _, opt := range splitArgs(attr[2 : len(attr)-1]) {
+			if len(opt) == 0 {
+				continue
+			}
+			key := opt[0].text
+			switch key {
+			case "rename", "rename_all", "alias", "default", "deny_unknown_fields", "bound":
+			case "with":
+				if len(opt) != 3 || opt[2].kind != tokString {
+					return f, fmt.Errorf("malformed serde(with)")
+				}
+				switch opt[2].text {
+				case "postcard::fixint::le":
+					f.fixint = "le"
+				case "postcard::fixint::be":
+					f.fixint = "be"
+				case "serde_bytes":
+					f.bytes = true
+				default:
+					return f, fmt.Errorf("unsupported serde(with = %q)", opt[2].text)
+				}
+			default:
+				return f, fmt.Errorf("unsupported serde attribute %q", key)
+			}
+		}
+	}
+	return f, nil
+}
+
+func splitArgs(toks []token) [][]token {
+	var out [][]token
+	depth, start := 0, 0
+	for i, t := range toks {
+		if t.kind != tokPunct {
+			continue
+		}
+		switch t.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case ",":
+			if depth == 0 {
+				out = append(out, toks[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(out, toks[start:])
+}
@@ -0,0 +1,94 @@
+//! Message types shared with the sensor firmware.
+#![no_std]
+
+use heapless::{String, Vec};
+use serde::{Deserialize, Serialize};
+
+pub const MAX_SAMPLES: usize = 8;
+
+/// Register address on the sensor bus.
+pub type Register = u32;
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+pub enum Mode {
+    Idle,
+    Sampling = 5,
+    Fault,
+}
+
+#[derive(Debug, Serialize, Deserialize)]
+pub struct Meters(pub f32);
+
+#[derive(Debug, Serialize, Deserialize)]
+pub struct Point(pub i16, pub i16);
+
+#[derive(Debug, Serialize, Deserialize)]
+pub struct Heartbeat;
+
+#[derive(Debug, Serialize, Deserialize)]
+pub struct RegisterWrite {
+    #[serde(with = "postcard::fixint::le")]
+    pub addr: Register,
+    #[serde(with = "postcard::fixint::be")]
+    pub value: u16,
+}
+
+#[derive(Debug, Serialize, Deserialize)]
+#[serde(rename_all = "camelCase")]
+pub struct Status<'a> {
+    pub device_id: u32,
+    pub name: String<16>,
+    pub label: &'a str,
+    pub mode: Mode,
+    pub samples: Vec<Meters, 8>,
+    pub raw: alloc::vec::Vec<u8>,
+    pub position: Option<Point>,
+    pub window: [u8; 4],
+    pub counters: BTreeMap<u8, u64>,
+    pub uptime_ns: u128,
+    pub unit: char,
+    pub range: (i16, i16),
+    pub uptime: core::time::Duration,
+    pub iq: Vec<num_complex::Complex<f32>, 8>,
+    pub nickname: Option<String<8>>,
+    pub history: Option<Vec<i16, 4>>,
+    pub r#type: bool,
+}
+
+#[derive(Debug, Serialize, Deserialize)]
+pub enum Command {
+    Ping,
+    Reset { delay_ms: u32 },
+    Write(RegisterWrite),
+    Move(i16, i16),
+    Calibrate(Option<Meters>),
+}
+
+#[derive(Debug, Serialize, Deserialize)]
+pub struct Reply(Option<Command>);
+
+#[derive(Debug, Serialize, Deserialize)]
+pub struct Network {
+    pub gateway: core::net::IpAddr,
+    pub server: std::net::SocketAddr,
+    pub subnet: ipnet::IpNet,
+    pub mask: Ipv4Addr,
+    pub id: uuid::Uuid,
+    #[serde(with = "serde_bytes")]
+    pub mac: [u8; 6],
+}
+
+#[derive(Debug, Serialize, Deserialize)]
+pub struct Ack(Result<(), Mode>);
+
+impl Status<'_> {
+    pub fn healthy(&self) -> bool {
+        matches!(self.mode, Mode::Idle | Mode::Sampling)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    #[test]
+    fn it_works() {}
+}
@@ -0,0 +1,71 @@
+// Command postcard-rustgen writes Rust type definitions for the types of a Go
+// package marked with the //postcard:generate directive, so firmware and
+// host share one definition of the wire format.
+//
+// Go types map to Rust as follows:
+//
+//	bool, int8..int64, uint8..uint64  bool, i8..i64, u8..u64
+//	int, uint, postcard.Varint        i64, u64, u64
+//	float32, float64                  f32, f64
+//	complex64, complex128             num_complex::Complex<f32>, <f64>
+//	string, []byte                    String, Vec<u8>
+//	[]T, [N]T, map[K]V                Vec<T>, [T; N], BTreeMap<K, V>
+//	postcard.Option[T]                Option<T>
+//	postcard.Tuple2..Tuple8, Unit     (A, B) .. (A, .., H), ()
+//	postcard.Result[T, E]             Result<T, E>
+//	postcard.Vec[T, N], String[N]     heapless::Vec<T, N>, heapless::String<N>
+//	time.Duration, postcard.Duration  core::time::Duration
+//	time.Time, postcard.Unix*         i64 Unix timestamp
+//	netip.Addr, AddrPort, Prefix      core::net::IpAddr, SocketAddr, ipnet::IpNet
+//	postcard.UUID                     uuid::Uuid
+//	interface registered with         enum, one variant per registered type
+//	postcard.RegisterEnum
+//	uint32 type with constants 0..n-1 C-like enum
+//
+// Field tags refine the mapping: `postcard:"max=N"` turns Vec, String and
+// maps into heapless::Vec, heapless::String and heapless::LinearMap with
+// capacity N, and `postcard:"fixint=le"` or `postcard:"fixint=be"` adds
+// #[serde(with = "postcard::fixint::le")] (or ::be) to an integer field.
+// `postcard:"unix=ms"` and the like only change the unit of a time.Time,
+// which stays an i64.
+// `postcard:"bytes"` adds #[serde(with = "serde_bytes")] to a byte array.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/yixinin/postcard-go/internal/load"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "package directory")
+	output := flag.String("o", "", "output file; standard output if empty")
+	flag.Parse()
+
+	if err := run(*dir, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "postcard-rustgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, output string) error {
+	pkg, err := load.Dir(dir, "postcard_gen.go")
+	if err != nil {
+		return err
+	}
+	if len(pkg.Marked) == 0 {
+		return fmt.Errorf("no types marked with %s in %s", load.Directive, dir)
+	}
+
+	src, err := Generate(pkg)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(output, src, 0o644)
+}
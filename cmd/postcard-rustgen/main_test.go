@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yixinin/postcard-go/internal/load"
+)
+
+func TestGenerateGolden(t *testing.T) {
+	pkg, err := load.Dir("testdata/icd")
+	if err != nil {
+		t.Fatalf("load.Dir error = %v", err)
+	}
+	got, err := Generate(pkg)
+	if err != nil {
+		t.Fatalf("Generate error = %v", err)
+	}
+
+	golden := filepath.Join("testdata", "icd.rs")
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("ReadFile error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Generate() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestGenerateErrors declares every case in one package, so the postcard
+// import is type-checked once, and generates each case's type on its own.
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"Pointer", "type Pointer struct{ P *int }", "use postcard.Option"},
+		{"FixintOnString", "type FixintOnString struct{ S string `postcard:\"fixint=le\"` }", "fixint needs"},
+		{"BadMax", "type BadMax struct{ S string `postcard:\"max=x\"` }", "max must be a positive integer"},
+		{"UnknownOption", "type UnknownOption struct{ N uint32 `postcard:\"fixnt=le\"` }", `unknown option "fixnt"`},
+		{"MaxWithFixint", "type MaxWithFixint struct{ N []uint32 `postcard:\"fixint=le,max=2\"` }", "max cannot be combined with fixint"},
+		{"CustomCapacity", "type CustomCapacity struct{ V postcard.Vec[int, N3] }\ntype N3 struct{}\nfunc (N3) Capacity() int { return 3 }", "use a max tag"},
+		{"NestedFixint", "type NestedFixint struct{ V []postcard.FixU32LE }", "only supported as a struct field"},
+		{"CharOnString", "type CharOnString struct{ S string `postcard:\"char\"` }", "char needs a rune"},
+		{"UnixOnInt", "type UnixOnInt struct{ N int64 `postcard:\"unix=ms\"` }", "unix needs a time.Time"},
+		{"BytesOnInt", "type BytesOnInt struct{ N [2]int `postcard:\"bytes\"` }", "bytes needs a byte array"},
+		{"Unexported", "type Unexported struct{ a, b int }", "no exported fields"},
+		{"UnregisteredEnum", "type I interface{ M() }\ntype UnregisteredEnum struct{ V I }", "not registered"},
+	}
+
+	src := "package bad\n\nimport \"github.com/yixinin/postcard-go/postcard\"\n\nvar _ postcard.Varint\n"
+	for _, tt := range tests {
+		src += "\n//postcard:generate\n" + tt.src + "\n"
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bad.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	all, err := load.Dir(dir)
+	if err != nil {
+		t.Fatalf("load.Dir error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, ok := all.Types.Scope().Lookup(tt.name).(*types.TypeName)
+			if !ok {
+				t.Fatalf("no type %s", tt.name)
+			}
+			pkg := *all
+			pkg.Marked = []*types.TypeName{obj}
+			_, err := Generate(&pkg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Generate error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":        "id",
+		"DeviceID":  "device_id",
+		"HTTPCode":  "http_code",
+		"RawData":   "raw_data",
+		"Value2":    "value2",
+		"Value2Max": "value2_max",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
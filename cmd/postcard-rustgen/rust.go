@@ -0,0 +1,564 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yixinin/postcard-go/internal/fieldtag"
+	"github.com/yixinin/postcard-go/internal/load"
+)
+
+const postcardPath = "github.com/yixinin/postcard-go/postcard"
+
+const derive = "#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]"
+
+var basicTypes = map[types.BasicKind]string{
+	types.Bool:       "bool",
+	types.Int8:       "i8",
+	types.Int16:      "i16",
+	types.Int32:      "i32",
+	types.Int64:      "i64",
+	types.Int:        "i64",
+	types.Uint8:      "u8",
+	types.Uint16:     "u16",
+	types.Uint32:     "u32",
+	types.Uint64:     "u64",
+	types.Uint:       "u64",
+	types.Float32:    "f32",
+	types.Float64:    "f64",
+	types.Complex64:  "num_complex::Complex<f32>",
+	types.Complex128: "num_complex::Complex<f64>",
+	types.String:     "String",
+}
+
+// fixintTypes are the Rust integer types postcard::fixint accepts.
+var fixintTypes = map[string]bool{
+	"u16": true, "u32": true, "u64": true,
+	"i16": true, "i32": true, "i64": true,
+}
+
+// fixintWrappers are the postcard.Fix* types, which stand for a Rust
+// integer field with a postcard::fixint attribute.
+var fixintWrappers = map[string]struct{ ty, order string }{
+	"FixU16LE": {"u16", "le"}, "FixU32LE": {"u32", "le"}, "FixU64LE": {"u64", "le"},
+	"FixI16LE": {"i16", "le"}, "FixI32LE": {"i32", "le"}, "FixI64LE": {"i64", "le"},
+	"FixU16BE": {"u16", "be"}, "FixU32BE": {"u32", "be"}, "FixU64BE": {"u64", "be"},
+	"FixI16BE": {"i16", "be"}, "FixI32BE": {"i32", "be"}, "FixI64BE": {"i64", "be"},
+}
+
+// fixintWrapper returns the Rust type and byte order of t if it is one of
+// the postcard.Fix* types.
+func fixintWrapper(t types.Type) (ty, order string, ok bool) {
+	named, isNamed := t.(*types.Named)
+	if !isNamed || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != postcardPath {
+		return "", "", false
+	}
+	w, ok := fixintWrappers[named.Obj().Name()]
+	return w.ty, w.order, ok
+}
+
+var rustKeywords = map[string]bool{
+	"as": true, "async": true, "await": true, "box": true, "break": true,
+	"const": true, "continue": true, "crate": true, "dyn": true, "else": true,
+	"enum": true, "extern": true, "false": true, "fn": true, "for": true,
+	"if": true, "impl": true, "in": true, "let": true, "loop": true,
+	"match": true, "mod": true, "move": true, "mut": true, "pub": true,
+	"ref": true, "return": true, "static": true, "struct": true,
+	"trait": true, "true": true, "type": true, "unsafe": true, "use": true,
+	"where": true, "while": true, "yield": true,
+}
+
+type rustGen struct {
+	pkg *load.Package
+	// enums maps interface types registered with postcard.RegisterEnum to
+	// their variant types.
+	enums  map[*types.TypeName][]types.Type
+	queued map[*types.TypeName]bool
+	queue  []*types.TypeName
+}
+
+// Generate returns Rust definitions for the marked types of pkg and every
+// type of pkg they refer to. Struct variants of enums are written inline and
+// only get a struct of their own when referenced elsewhere.
+func Generate(pkg *load.Package) ([]byte, error) {
+	g := &rustGen{
+		pkg:    pkg,
+		enums:  map[*types.TypeName][]types.Type{},
+		queued: map[*types.TypeName]bool{},
+	}
+	g.findEnums()
+	for _, obj := range pkg.Marked {
+		g.enqueue(obj)
+	}
+
+	var items []string
+	for len(g.queue) > 0 {
+		obj := g.queue[0]
+		g.queue = g.queue[1:]
+		item, err := g.item(obj)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", obj.Name(), err)
+		}
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by postcard-rustgen from package %s. DO NOT EDIT.\n\n", pkg.Types.Name())
+	out.WriteString("use serde::{Deserialize, Serialize};\n")
+	for _, item := range items {
+		out.WriteString("\n")
+		out.WriteString(item)
+	}
+	return out.Bytes(), nil
+}
+
+// findEnums records the type arguments and variants of every
+// postcard.RegisterEnum call in the package.
+func (g *rustGen) findEnums() {
+	for _, f := range g.pkg.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			id := calleeIdent(call.Fun)
+			if id == nil {
+				return true
+			}
+			fn, ok := g.pkg.Info.Uses[id].(*types.Func)
+			if !ok || fn.Pkg() == nil || fn.Pkg().Path() != postcardPath || fn.Name() != "RegisterEnum" {
+				return true
+			}
+			inst, ok := g.pkg.Info.Instances[id]
+			if !ok || inst.TypeArgs.Len() != 1 {
+				return true
+			}
+			named, ok := inst.TypeArgs.At(0).(*types.Named)
+			if !ok {
+				return true
+			}
+			var variants []types.Type
+			for _, arg := range call.Args {
+				variants = append(variants, g.pkg.Info.TypeOf(arg))
+			}
+			g.enums[named.Obj()] = variants
+			return true
+		})
+	}
+}
+
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		return calleeIdent(f.X)
+	case *ast.IndexListExpr:
+		return calleeIdent(f.X)
+	case *ast.SelectorExpr:
+		return f.Sel
+	case *ast.Ident:
+		return f
+	}
+	return nil
+}
+
+func (g *rustGen) enqueue(obj *types.TypeName) {
+	if g.queued[obj] {
+		return
+	}
+	g.queued[obj] = true
+	g.queue = append(g.queue, obj)
+}
+
+func (g *rustGen) item(obj *types.TypeName) (string, error) {
+	named := obj.Type().(*types.Named)
+	if named.TypeParams().Len() > 0 {
+		return "", fmt.Errorf("generic types are not supported")
+	}
+
+	var b bytes.Buffer
+	switch u := named.Underlying().(type) {
+	case *types.Struct:
+		fields, err := g.fields(u, "    pub ")
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(derive + "\n")
+		if fields == "" {
+			fmt.Fprintf(&b, "pub struct %s;\n", obj.Name())
+		} else {
+			fmt.Fprintf(&b, "pub struct %s {\n%s}\n", obj.Name(), fields)
+		}
+	case *types.Interface:
+		variants, ok := g.enums[obj]
+		if !ok {
+			return "", fmt.Errorf("interface is not registered with postcard.RegisterEnum")
+		}
+		body, err := g.enumVariants(variants)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s\npub enum %s {\n%s}\n", derive, obj.Name(), body)
+	case *types.Basic:
+		if consts := g.constants(named); g.pkg.IsMarked(obj) && isCLikeEnum(u, consts) {
+			b.WriteString("#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]\n")
+			fmt.Fprintf(&b, "pub enum %s {\n", obj.Name())
+			for _, c := range consts {
+				fmt.Fprintf(&b, "    %s,\n", variantName(obj.Name(), c.Name()))
+			}
+			b.WriteString("}\n")
+			return b.String(), nil
+		}
+		ty, err := g.rustType(u, fieldtag.Options{})
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "pub type %s = %s;\n", obj.Name(), ty)
+		for _, c := range g.constants(named) {
+			fmt.Fprintf(&b, "pub const %s: %s = %s;\n", screamingCase(c.Name()), obj.Name(), c.Val().ExactString())
+		}
+	default:
+		ty, err := g.rustType(u, fieldtag.Options{})
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "pub type %s = %s;\n", obj.Name(), ty)
+	}
+	return b.String(), nil
+}
+
+// fields renders the exported fields of s, one per line with the given
+// prefix. Unexported fields are not encoded, so they are left out; a struct
+// with fields but none exported is an error, as it is for the runtime.
+func (g *rustGen) fields(s *types.Struct, prefix string) (string, error) {
+	indent := prefix[:len(prefix)-len(strings.TrimLeft(prefix, " "))]
+	var b strings.Builder
+	for i := 0; i < s.NumFields(); i++ {
+		f := s.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		tag, err := fieldtag.Parse(reflect.StructTag(s.Tag(i)))
+		if err != nil {
+			return "", fmt.Errorf("field %s: %v", f.Name(), err)
+		}
+		var ty string
+		if wty, order, ok := fixintWrapper(f.Type()); ok {
+			ty, tag.Fixint = wty, order
+		} else if ty, err = g.rustType(f.Type(), tag); err != nil {
+			return "", fmt.Errorf("field %s: %v", f.Name(), err)
+		}
+		if tag.Char {
+			if resolved(ty, f.Type()) != "i32" {
+				return "", fmt.Errorf("field %s: char needs a rune, got %s", f.Name(), ty)
+			}
+			ty = "char"
+		}
+		if tag.Unix != "" && f.Type().String() != "time.Time" {
+			return "", fmt.Errorf("field %s: unix needs a time.Time, got %s", f.Name(), f.Type())
+		}
+		if tag.Fixint != "" {
+			if !fixintTypes[resolved(ty, f.Type())] {
+				return "", fmt.Errorf("field %s: fixint needs a 16, 32 or 64-bit integer, got %s", f.Name(), ty)
+			}
+			fmt.Fprintf(&b, "%s#[serde(with = \"postcard::fixint::%s\")]\n", indent, tag.Fixint)
+		}
+		if tag.Bytes {
+			switch u := f.Type().Underlying().(type) {
+			case *types.Array:
+				if resolved("", u.Elem()) != "u8" {
+					return "", fmt.Errorf("field %s: bytes needs a byte array, got %s", f.Name(), ty)
+				}
+				fmt.Fprintf(&b, "%s#[serde(with = \"serde_bytes\")]\n", indent)
+			case *types.Slice:
+				if resolved("", u.Elem()) != "u8" {
+					return "", fmt.Errorf("field %s: bytes needs a byte array, got %s", f.Name(), ty)
+				}
+			default:
+				return "", fmt.Errorf("field %s: bytes needs a byte array, got %s", f.Name(), ty)
+			}
+		}
+		fmt.Fprintf(&b, "%s%s: %s,\n", prefix, rustIdent(snakeCase(f.Name())), ty)
+	}
+	if b.Len() == 0 && s.NumFields() > 0 {
+		return "", fmt.Errorf("struct has no exported fields to encode")
+	}
+	return b.String(), nil
+}
+
+// resolved returns the primitive behind ty when t is a type alias such as
+// `type Register uint32`.
+func resolved(ty string, t types.Type) string {
+	if b, ok := t.Underlying().(*types.Basic); ok {
+		if name, ok := basicTypes[b.Kind()]; ok {
+			return name
+		}
+	}
+	return ty
+}
+
+func (g *rustGen) enumVariants(variants []types.Type) (string, error) {
+	var b strings.Builder
+	for _, vt := range variants {
+		if p, ok := vt.(*types.Pointer); ok {
+			vt = p.Elem()
+		}
+		named, ok := vt.(*types.Named)
+		if !ok {
+			return "", fmt.Errorf("variant %s is not a defined type", vt)
+		}
+		name := named.Obj().Name()
+		switch u := named.Underlying().(type) {
+		case *types.Struct:
+			fields, err := g.fields(u, "        ")
+			if err != nil {
+				return "", fmt.Errorf("variant %s: %v", name, err)
+			}
+			if fields == "" {
+				fmt.Fprintf(&b, "    %s,\n", name)
+			} else {
+				fmt.Fprintf(&b, "    %s {\n%s    },\n", name, fields)
+			}
+		default:
+			ty, err := g.rustType(u, fieldtag.Options{})
+			if err != nil {
+				return "", fmt.Errorf("variant %s: %v", name, err)
+			}
+			fmt.Fprintf(&b, "    %s(%s),\n", name, ty)
+		}
+	}
+	return b.String(), nil
+}
+
+func (g *rustGen) rustType(t types.Type, tag fieldtag.Options) (string, error) {
+	if named, ok := t.(*types.Named); ok {
+		obj := named.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "time" {
+			switch obj.Name() {
+			case "Duration":
+				return "core::time::Duration", nil
+			case "Time":
+				return "i64", nil
+			}
+		}
+		if obj.Pkg() != nil && obj.Pkg().Path() == "net/netip" {
+			switch obj.Name() {
+			case "Addr":
+				return "core::net::IpAddr", nil
+			case "AddrPort":
+				return "core::net::SocketAddr", nil
+			case "Prefix":
+				return "ipnet::IpNet", nil
+			}
+		}
+		if obj.Pkg() != nil && obj.Pkg().Path() == postcardPath {
+			switch obj.Name() {
+			case "Varint":
+				return "u64", nil
+			case "UUID":
+				return "uuid::Uuid", nil
+			case "Duration":
+				return "core::time::Duration", nil
+			case "UnixSeconds", "UnixMillis", "UnixMicros", "UnixNanos":
+				return "i64", nil
+			case "Char":
+				return "char", nil
+			case "Uint128":
+				return "u128", nil
+			case "Int128":
+				return "i128", nil
+			case "Option":
+				inner, err := g.rustType(named.TypeArgs().At(0), tag)
+				if err != nil {
+					return "", err
+				}
+				return "Option<" + inner + ">", nil
+			case "Unit":
+				return "()", nil
+			case "Vec":
+				elem, err := g.rustType(named.TypeArgs().At(0), fieldtag.Options{})
+				if err != nil {
+					return "", err
+				}
+				n, err := capacity(named.TypeArgs().At(1))
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("heapless::Vec<%s, %d>", elem, n), nil
+			case "String":
+				n, err := capacity(named.TypeArgs().At(0))
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("heapless::String<%d>", n), nil
+			case "Result":
+				ok, err := g.rustType(named.TypeArgs().At(0), fieldtag.Options{})
+				if err != nil {
+					return "", err
+				}
+				e, err := g.rustType(named.TypeArgs().At(1), fieldtag.Options{})
+				if err != nil {
+					return "", err
+				}
+				return "Result<" + ok + ", " + e + ">", nil
+			}
+			if strings.HasPrefix(obj.Name(), "Tuple") {
+				args := named.TypeArgs()
+				elems := make([]string, args.Len())
+				for i := range elems {
+					elem, err := g.rustType(args.At(i), fieldtag.Options{})
+					if err != nil {
+						return "", err
+					}
+					elems[i] = elem
+				}
+				return "(" + strings.Join(elems, ", ") + ")", nil
+			}
+			if _, ok := fixintWrappers[obj.Name()]; ok {
+				return "", fmt.Errorf("%s is only supported as a struct field; serde has no fixint for nested types", t)
+			}
+			return "", fmt.Errorf("unsupported type %s", t)
+		}
+		if obj.Pkg() != g.pkg.Types {
+			return "", fmt.Errorf("type %s is outside package %s", t, g.pkg.Types.Name())
+		}
+		g.enqueue(obj)
+		return obj.Name(), nil
+	}
+
+	switch u := t.(type) {
+	case *types.Basic:
+		name, ok := basicTypes[u.Kind()]
+		if !ok {
+			return "", fmt.Errorf("unsupported type %s", t)
+		}
+		if u.Kind() == types.String && tag.Max > 0 {
+			return fmt.Sprintf("heapless::String<%d>", tag.Max), nil
+		}
+		return name, nil
+	case *types.Slice:
+		elem, err := g.rustType(u.Elem(), fieldtag.Options{})
+		if err != nil {
+			return "", err
+		}
+		if tag.Max > 0 {
+			return fmt.Sprintf("heapless::Vec<%s, %d>", elem, tag.Max), nil
+		}
+		return "Vec<" + elem + ">", nil
+	case *types.Array:
+		elem, err := g.rustType(u.Elem(), fieldtag.Options{})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[%s; %d]", elem, u.Len()), nil
+	case *types.Map:
+		key, err := g.rustType(u.Key(), fieldtag.Options{})
+		if err != nil {
+			return "", err
+		}
+		val, err := g.rustType(u.Elem(), fieldtag.Options{})
+		if err != nil {
+			return "", err
+		}
+		if tag.Max > 0 {
+			return fmt.Sprintf("heapless::LinearMap<%s, %s, %d>", key, val, tag.Max), nil
+		}
+		return fmt.Sprintf("std::collections::BTreeMap<%s, %s>", key, val), nil
+	case *types.Pointer:
+		return "", fmt.Errorf("pointers do not encode as Option; use postcard.Option")
+	case *types.Struct:
+		return "", fmt.Errorf("anonymous struct types have no Rust equivalent")
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// capacity returns the N of a postcard.Vec or String, which must be one of
+// postcard.N1 to N1024: the generator cannot call the Capacity method of
+// other types.
+func capacity(t types.Type) (int, error) {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == postcardPath {
+		if n, err := strconv.Atoi(strings.TrimPrefix(named.Obj().Name(), "N")); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("capacity %s is not one of postcard.N1 to N1024; use a max tag instead", t)
+}
+
+// constants returns the package-level constants of type t ordered by value.
+func (g *rustGen) constants(t *types.Named) []*types.Const {
+	var consts []*types.Const
+	scope := g.pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if ok && types.Identical(c.Type(), t) {
+			consts = append(consts, c)
+		}
+	}
+	sort.SliceStable(consts, func(i, j int) bool {
+		return constant.Compare(consts[i].Val(), token.LSS, consts[j].Val())
+	})
+	return consts
+}
+
+// isCLikeEnum reports whether constants 0..n-1 of a uint32 type encode the
+// same as serde's unit variants, which are varint u32 discriminants.
+func isCLikeEnum(b *types.Basic, consts []*types.Const) bool {
+	if b.Kind() != types.Uint32 || len(consts) == 0 {
+		return false
+	}
+	for i, c := range consts {
+		v, ok := constant.Uint64Val(c.Val())
+		if !ok || v != uint64(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func variantName(typeName, constName string) string {
+	if name := strings.TrimPrefix(constName, typeName); name != "" && name != constName {
+		return name
+	}
+	return constName
+}
+
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func screamingCase(s string) string {
+	return strings.ToUpper(snakeCase(s))
+}
+
+func rustIdent(s string) string {
+	if rustKeywords[s] {
+		return "r#" + s
+	}
+	return s
+}
@@ -0,0 +1,68 @@
+// Code generated by postcard-rustgen from package icd. DO NOT EDIT.
+
+use serde::{Deserialize, Serialize};
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+pub enum State {
+    Idle,
+    Running,
+    Fault,
+}
+
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct Telemetry {
+    pub device_id: u32,
+    pub name: heapless::String<16>,
+    pub state: State,
+    #[serde(with = "postcard::fixint::le")]
+    pub reg: Register,
+    #[serde(with = "postcard::fixint::be")]
+    pub raw: u64,
+    pub offset: i16,
+    #[serde(with = "postcard::fixint::be")]
+    pub gain: i32,
+    pub count: u64,
+    pub total: u128,
+    pub initial: char,
+    pub symbol: char,
+    pub span: (u8, String),
+    pub samples: heapless::Vec<Sample, 8>,
+    pub payload: Vec<u8>,
+    pub window: [i8; 4],
+    pub labels: std::collections::BTreeMap<String, u8>,
+    pub outline: Option<Shape>,
+    pub last: Result<Sample, State>,
+    pub uptime: core::time::Duration,
+    pub seen: i64,
+    pub iq: Vec<num_complex::Complex<f32>>,
+    pub peer: core::net::SocketAddr,
+    pub session: uuid::Uuid,
+    #[serde(with = "serde_bytes")]
+    pub mac: [u8; 6],
+    pub alias: heapless::String<8>,
+    pub recent: heapless::Vec<i16, 4>,
+    pub r#type: bool,
+}
+
+pub type Register = u32;
+pub const REGISTER_CONTROL: Register = 16;
+pub const REGISTER_STATUS: Register = 20;
+
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct Sample {
+    pub at: u64,
+    pub value: f64,
+}
+
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub enum Shape {
+    Circle {
+        radius: f32,
+    },
+    Rect {
+        width: u16,
+        height: u16,
+    },
+    Unknown,
+    Code(u16),
+}
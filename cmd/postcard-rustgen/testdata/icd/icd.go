@@ -0,0 +1,84 @@
+package icd
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+type Register uint32
+
+const (
+	RegisterControl Register = 0x10
+	RegisterStatus  Register = 0x14
+)
+
+//postcard:generate
+type State uint32
+
+const (
+	StateIdle State = iota
+	StateRunning
+	StateFault
+)
+
+type Shape interface{ isShape() }
+
+type Circle struct {
+	Radius float32
+}
+
+type Rect struct {
+	Width, Height uint16
+}
+
+type Unknown struct{}
+
+type Code uint16
+
+func (Circle) isShape()  {}
+func (*Rect) isShape()   {}
+func (Unknown) isShape() {}
+func (Code) isShape()    {}
+
+func init() {
+	postcard.RegisterEnum[Shape](Circle{}, &Rect{}, Unknown{}, Code(0))
+}
+
+type Sample struct {
+	At    uint64
+	Value float64
+}
+
+//postcard:generate
+type Telemetry struct {
+	DeviceID uint32
+	Name     string `postcard:"max=16"`
+	State    State
+	Reg      Register `postcard:"fixint=le"`
+	Raw      uint64   `postcard:"fixint=be"`
+	Offset   int16
+	Gain     postcard.FixI32BE
+	Count    postcard.Varint
+	Total    postcard.Uint128
+	Initial  rune `postcard:"char"`
+	Symbol   postcard.Char
+	Span     postcard.Tuple2[uint8, string]
+	Samples  []Sample `postcard:"max=8"`
+	Payload  []byte
+	Window   [4]int8
+	Labels   map[string]uint8
+	Outline  postcard.Option[Shape]
+	Last     postcard.Result[Sample, State]
+	Uptime   time.Duration
+	Seen     time.Time `postcard:"unix=ms"`
+	IQ       []complex64
+	Peer     netip.AddrPort
+	Session  postcard.UUID
+	Mac      [6]byte `postcard:"bytes"`
+	Alias    postcard.String[postcard.N8]
+	Recent   postcard.Vec[int16, postcard.N4]
+	Type     bool
+	internal int
+}
@@ -0,0 +1,101 @@
+// Package fieldtag parses `postcard:"..."` struct field tags. The runtime,
+// postcard-gen and postcard-rustgen all parse tags here, so they accept the
+// same tags and reject the same mistakes.
+package fieldtag
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Options are the options of a postcard field tag, a comma separated list
+// such as `postcard:"fixint=le"`. Whether the field's type suits them is
+// left to the caller.
+type Options struct {
+	// Fixint is "le" or "be" for integers encoded in full width, as with
+	// #[serde(with = "postcard::fixint::le")].
+	Fixint string
+	// Char marks a rune encoded as a Rust char.
+	Char bool
+	// Unix is the unit of a time.Time encoded as a Unix timestamp: "s",
+	// "ms", "us" or "ns".
+	Unix string
+	// Bytes marks a byte array encoded as length-prefixed bytes, as with
+	// #[serde(with = "serde_bytes")], rather than as a tuple. Byte slices
+	// always encode that way, so it changes nothing for them.
+	Bytes bool
+	// Max bounds the length of a slice, string or map, or of the value of
+	// an Option of one, as with heapless::Vec<T, N>, heapless::String<N> or
+	// heapless::LinearMap. It is 0 for no bound.
+	Max int
+}
+
+// Parse returns the postcard options of tag. It rejects unknown or repeated
+// options and malformed arguments. Fixint, char, unix and bytes each choose
+// the encoding of the field, so at most one of them may be given, and none
+// but bytes combines with max, which bounds a collection.
+func Parse(tag reflect.StructTag) (Options, error) {
+	var o Options
+	value, ok := tag.Lookup("postcard")
+	if !ok {
+		return o, nil
+	}
+	seen := map[string]bool{}
+	for _, opt := range strings.Split(value, ",") {
+		key, arg, hasArg := strings.Cut(strings.TrimSpace(opt), "=")
+		if key == "" && !hasArg {
+			continue
+		}
+		if seen[key] {
+			return o, fmt.Errorf("%s given twice", key)
+		}
+		seen[key] = true
+		switch key {
+		case "fixint":
+			if arg != "le" && arg != "be" {
+				return o, fmt.Errorf("fixint must be le or be, got %q", arg)
+			}
+			o.Fixint = arg
+		case "unix":
+			switch arg {
+			case "s", "ms", "us", "ns":
+			default:
+				return o, fmt.Errorf("unix must be s, ms, us or ns, got %q", arg)
+			}
+			o.Unix = arg
+		case "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return o, fmt.Errorf("max must be a positive integer, got %q", arg)
+			}
+			o.Max = n
+		case "char", "bytes":
+			if hasArg {
+				return o, fmt.Errorf("%s takes no argument, got %q", key, arg)
+			}
+			if key == "char" {
+				o.Char = true
+			} else {
+				o.Bytes = true
+			}
+		default:
+			return o, fmt.Errorf("unknown option %q", key)
+		}
+	}
+
+	var encodings []string
+	for _, key := range []string{"fixint", "char", "unix", "bytes"} {
+		if seen[key] {
+			encodings = append(encodings, key)
+		}
+	}
+	if len(encodings) > 1 {
+		return o, fmt.Errorf("%s cannot be combined", strings.Join(encodings, " and "))
+	}
+	if o.Max > 0 && len(encodings) == 1 && encodings[0] != "bytes" {
+		return o, fmt.Errorf("max cannot be combined with %s", encodings[0])
+	}
+	return o, nil
+}
@@ -0,0 +1,48 @@
+package fieldtag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	valid := map[reflect.StructTag]Options{
+		``:                           {},
+		`json:"x"`:                   {},
+		`postcard:""`:                {},
+		`postcard:"fixint=be"`:       {Fixint: "be"},
+		`postcard:"char"`:            {Char: true},
+		`postcard:"unix=us"`:         {Unix: "us"},
+		`postcard:"bytes"`:           {Bytes: true},
+		`postcard:"max=8"`:           {Max: 8},
+		`postcard:" bytes , max=4 "`: {Bytes: true, Max: 4},
+		`json:"x" postcard:"max=2,"`: {Max: 2},
+	}
+	for tag, want := range valid {
+		got, err := Parse(tag)
+		if err != nil || got != want {
+			t.Errorf("Parse(%s) = %+v, %v, want %+v", tag, got, err, want)
+		}
+	}
+
+	invalid := map[reflect.StructTag]string{
+		`postcard:"fixnt=le"`:        `unknown option "fixnt"`,
+		`postcard:"=le"`:             `unknown option ""`,
+		`postcard:"fixint=middle"`:   "fixint must be le or be",
+		`postcard:"unix=h"`:          "unix must be s, ms, us or ns",
+		`postcard:"max=0"`:           "max must be a positive integer",
+		`postcard:"char=yes"`:        "char takes no argument",
+		`postcard:"max=2,max=3"`:     "max given twice",
+		`postcard:"fixint=le,char"`:  "fixint and char cannot be combined",
+		`postcard:"bytes,unix=s"`:    "unix and bytes cannot be combined",
+		`postcard:"fixint=le,max=4"`: "max cannot be combined with fixint",
+		`postcard:"max=4,char"`:      "max cannot be combined with char",
+		`postcard:"unix=ms,max=4"`:   "max cannot be combined with unix",
+	}
+	for tag, want := range invalid {
+		if _, err := Parse(tag); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%s) error = %v, want %q", tag, err, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package gentest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+// TestHostileLength feeds generated decoders slice lengths the input cannot
+// hold. They must fail like the reflective path instead of panicking or
+// allocating what the length claims.
+func TestHostileLength(t *testing.T) {
+	inputs := map[string][]byte{
+		"out of range": {0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40},
+		"gigabytes":    {0x80, 0x80, 0x80, 0x80, 0x10, 0x01, 0x02},
+	}
+	for name, data := range inputs {
+		t.Run(name, func(t *testing.T) {
+			var ref Samples
+			refErr := postcard.Deserialize(data, (*[]int)(&ref))
+			if refErr == nil {
+				t.Fatalf("Deserialize(%v) succeeded", data)
+			}
+
+			var v Samples
+			err := v.UnmarshalPostcard(postcard.NewDeserializer(data))
+			if !errors.Is(err, postcard.ErrDeserializeUnexpectedEnd) {
+				t.Errorf("UnmarshalPostcard(%v) error = %v, want %v", data, err, postcard.ErrDeserializeUnexpectedEnd)
+			}
+		})
+	}
+}
+
+// TestHostileZeroSizeLength feeds generated decoders a huge length of
+// elements that consume no input, which must fail rather than loop.
+func TestHostileZeroSizeLength(t *testing.T) {
+	data := []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}
+	var ref []postcard.Unit
+	if err := postcard.Deserialize(data, &ref); !errors.Is(err, postcard.ErrCapacityExceeded) {
+		t.Errorf("Deserialize(%v) error = %v, want %v", data, err, postcard.ErrCapacityExceeded)
+	}
+	var v Ticks
+	if err := v.UnmarshalPostcard(postcard.NewDeserializer(data)); !errors.Is(err, postcard.ErrCapacityExceeded) {
+		t.Errorf("UnmarshalPostcard(%v) error = %v, want %v", data, err, postcard.ErrCapacityExceeded)
+	}
+
+	if err := v.UnmarshalPostcard(postcard.NewDeserializer([]byte{0x03})); err != nil || len(v) != 3 {
+		t.Errorf("UnmarshalPostcard(3 ticks) = %d, %v", len(v), err)
+	}
+}
+
+// TestBoundedLength feeds generated decoders a length one past each field's
+// max, followed by nothing. They must fail with ErrCapacityExceeded on the
+// length, before allocating or reading any element.
+func TestBoundedLength(t *testing.T) {
+	inputs := map[string][]byte{
+		"slice":  {0x05},
+		"string": {0x00, 0xC9, 0x01},
+		"bytes":  {0x00, 0x00, 0x05},
+		"map":    {0x00, 0x00, 0x00, 0x05},
+		"option": {0x00, 0x00, 0x00, 0x00, 0x01, 0x05},
+	}
+	for name, data := range inputs {
+		t.Run(name, func(t *testing.T) {
+			type plain Bounded
+			var ref plain
+			if err := postcard.Deserialize(data, &ref); !errors.Is(err, postcard.ErrCapacityExceeded) {
+				t.Errorf("Deserialize(%v) error = %v, want %v", data, err, postcard.ErrCapacityExceeded)
+			}
+
+			var v Bounded
+			err := v.UnmarshalPostcard(postcard.NewDeserializer(data))
+			if !errors.Is(err, postcard.ErrCapacityExceeded) {
+				t.Errorf("UnmarshalPostcard(%v) error = %v, want %v", data, err, postcard.ErrCapacityExceeded)
+			}
+		})
+	}
+
+	long := Bounded{Note: postcard.Some([]byte{1, 2, 3, 4, 5})}
+	if err := long.MarshalPostcard(postcard.NewSerializer(nil)); !errors.Is(err, postcard.ErrCapacityExceeded) {
+		t.Errorf("MarshalPostcard(%+v) error = %v, want %v", long, err, postcard.ErrCapacityExceeded)
+	}
+}
@@ -0,0 +1,691 @@
+// Code generated by postcard-gen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"github.com/yixinin/postcard-go/postcard"
+	"time"
+)
+
+// MarshalPostcard encodes v without reflection.
+func (v Reading) MarshalPostcard(s *postcard.Serializer) error {
+	if err := s.SerializeUint16(v.Channel); err != nil {
+		return err
+	}
+	if err := s.SerializeUint8(uint8(v.Mode)); err != nil {
+		return err
+	}
+	if err := s.SerializeFloat32(v.Value); err != nil {
+		return err
+	}
+	if err := s.SerializeInt32(v.Delta); err != nil {
+		return err
+	}
+	if err := s.SerializeBool(v.Valid); err != nil {
+		return err
+	}
+	if err := s.SerializeString(v.Label); err != nil {
+		return err
+	}
+	if err := s.SerializeBytes(v.Raw); err != nil {
+		return err
+	}
+	if err := s.SerializeVarInt(v.Count); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalPostcard decodes v without reflection.
+func (v *Reading) UnmarshalPostcard(d *postcard.Deserializer) error {
+	{
+		x1, err := d.DeserializeUint16()
+		if err != nil {
+			return err
+		}
+		v.Channel = x1
+	}
+	{
+		x2, err := d.DeserializeUint8()
+		if err != nil {
+			return err
+		}
+		v.Mode = Mode(x2)
+	}
+	{
+		x3, err := d.DeserializeFloat32()
+		if err != nil {
+			return err
+		}
+		v.Value = x3
+	}
+	{
+		x4, err := d.DeserializeInt32()
+		if err != nil {
+			return err
+		}
+		v.Delta = x4
+	}
+	{
+		x5, err := d.DeserializeBool()
+		if err != nil {
+			return err
+		}
+		v.Valid = x5
+	}
+	{
+		x6, err := d.DeserializeString()
+		if err != nil {
+			return err
+		}
+		v.Label = x6
+	}
+	{
+		b7, err := d.DeserializeBytes()
+		if err != nil {
+			return err
+		}
+		v.Raw = b7
+	}
+	{
+		x8, err := d.DeserializeVarint()
+		if err != nil {
+			return err
+		}
+		v.Count = x8
+	}
+	return nil
+}
+
+// SizePostcard returns the encoded size of v.
+func (v Reading) SizePostcard() int {
+	n := 0
+	n += postcard.SizeOfUint(uint64(v.Channel))
+	n += 1
+	n += 4
+	n += postcard.SizeOfInt(int64(v.Delta))
+	n += 1
+	n += postcard.SizeOfString(v.Label)
+	n += postcard.SizeOfBytes(v.Raw)
+	n += postcard.SizeOfUint(uint64(v.Count))
+	return n
+}
+
+// MarshalPostcard encodes v without reflection.
+func (v Frame) MarshalPostcard(s *postcard.Serializer) error {
+	if err := s.SerializeUint32(v.ID); err != nil {
+		return err
+	}
+	if err := s.SerializeUint(uint(len(v.Readings))); err != nil {
+		return err
+	}
+	for i9 := range v.Readings {
+		if err := v.Readings[i9].MarshalPostcard(s); err != nil {
+			return err
+		}
+	}
+	for i10 := range v.Window {
+		if err := s.SerializeInt16(v.Window[i10]); err != nil {
+			return err
+		}
+	}
+	for i11 := range v.Calib {
+		if err := s.SerializeFloat64(v.Calib[i11]); err != nil {
+			return err
+		}
+	}
+	if err := s.SerializeUint(uint(len(v.Flags))); err != nil {
+		return err
+	}
+	for i12 := range v.Flags {
+		if err := s.SerializeBool(v.Flags[i12]); err != nil {
+			return err
+		}
+	}
+	if err := s.SerializeUint(uint(len(v.Tags))); err != nil {
+		return err
+	}
+	for k13, e14 := range v.Tags {
+		if err := s.SerializeString(k13); err != nil {
+			return err
+		}
+		if err := s.SerializeUint64(e14); err != nil {
+			return err
+		}
+	}
+	if err := s.SerializeInt64(v.Inner.Lo); err != nil {
+		return err
+	}
+	if err := s.SerializeInt64(v.Inner.Hi); err != nil {
+		return err
+	}
+	if err := postcard.FixU32BE(v.Addr).MarshalPostcard(s); err != nil {
+		return err
+	}
+	if err := v.Offset.MarshalPostcard(s); err != nil {
+		return err
+	}
+	if err := postcard.Char(v.Key).MarshalPostcard(s); err != nil {
+		return err
+	}
+	if err := v.Alt.MarshalPostcard(s); err != nil {
+		return err
+	}
+	if err := postcard.Duration(v.Took).MarshalPostcard(s); err != nil {
+		return err
+	}
+	if err := postcard.UnixMillis(v.Stamp).MarshalPostcard(s); err != nil {
+		return err
+	}
+	if err := s.SerializeComplex128(v.Phase); err != nil {
+		return err
+	}
+	if err := s.SerializeUint(uint(len(v.IQ))); err != nil {
+		return err
+	}
+	for i15 := range v.IQ {
+		if err := s.SerializeComplex64(v.IQ[i15]); err != nil {
+			return err
+		}
+	}
+	if err := s.SerializeAddrPort(v.Peer); err != nil {
+		return err
+	}
+	if err := s.SerializePrefix(v.Subnet); err != nil {
+		return err
+	}
+	if err := v.Owner.MarshalPostcard(s); err != nil {
+		return err
+	}
+	if err := s.SerializeBytes(v.Mac[:]); err != nil {
+		return err
+	}
+	if err := postcard.CheckMax(len(v.Recent), 4); err != nil {
+		return err
+	}
+	if err := s.SerializeUint(uint(len(v.Recent))); err != nil {
+		return err
+	}
+	for i16 := range v.Recent {
+		if err := s.SerializeUint16(v.Recent[i16]); err != nil {
+			return err
+		}
+	}
+	if err := v.Burst.MarshalPostcard(s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalPostcard decodes v without reflection.
+func (v *Frame) UnmarshalPostcard(d *postcard.Deserializer) error {
+	{
+		x17, err := d.DeserializeUint32()
+		if err != nil {
+			return err
+		}
+		v.ID = x17
+	}
+	{
+		n18, err := d.DeserializeUint()
+		if err != nil {
+			return err
+		}
+		if err := d.CheckLen(n18, 8); err != nil {
+			return err
+		}
+		v.Readings = make([]Reading, 0, d.PreallocLen(n18))
+		for i19 := uint(0); i19 < n18; i19++ {
+			var e20 Reading
+			if err := e20.UnmarshalPostcard(d); err != nil {
+				return err
+			}
+			v.Readings = append(v.Readings, e20)
+		}
+	}
+	{
+		for i21 := range v.Window {
+			x22, err := d.DeserializeInt16()
+			if err != nil {
+				return err
+			}
+			v.Window[i21] = x22
+		}
+	}
+	{
+		for i23 := range v.Calib {
+			x24, err := d.DeserializeFloat64()
+			if err != nil {
+				return err
+			}
+			v.Calib[i23] = x24
+		}
+	}
+	{
+		n25, err := d.DeserializeUint()
+		if err != nil {
+			return err
+		}
+		if err := d.CheckLen(n25, 1); err != nil {
+			return err
+		}
+		v.Flags = make([]bool, 0, d.PreallocLen(n25))
+		for i26 := uint(0); i26 < n25; i26++ {
+			var e27 bool
+			x28, err := d.DeserializeBool()
+			if err != nil {
+				return err
+			}
+			e27 = x28
+			v.Flags = append(v.Flags, e27)
+		}
+	}
+	{
+		n29, err := d.DeserializeUint()
+		if err != nil {
+			return err
+		}
+		if err := d.CheckLen(n29, 2); err != nil {
+			return err
+		}
+		if v.Tags == nil {
+			v.Tags = make(map[string]uint64)
+		}
+		for i30 := uint(0); i30 < n29; i30++ {
+			var k31 string
+			x33, err := d.DeserializeString()
+			if err != nil {
+				return err
+			}
+			k31 = x33
+			var e32 uint64
+			x34, err := d.DeserializeUint64()
+			if err != nil {
+				return err
+			}
+			e32 = x34
+			v.Tags[k31] = e32
+		}
+	}
+	{
+		{
+			x35, err := d.DeserializeInt64()
+			if err != nil {
+				return err
+			}
+			v.Inner.Lo = x35
+		}
+		{
+			x36, err := d.DeserializeInt64()
+			if err != nil {
+				return err
+			}
+			v.Inner.Hi = x36
+		}
+	}
+	{
+		var x37 postcard.FixU32BE
+		if err := x37.UnmarshalPostcard(d); err != nil {
+			return err
+		}
+		v.Addr = uint32(x37)
+	}
+	{
+		if err := v.Offset.UnmarshalPostcard(d); err != nil {
+			return err
+		}
+	}
+	{
+		var x38 postcard.Char
+		if err := x38.UnmarshalPostcard(d); err != nil {
+			return err
+		}
+		v.Key = rune(x38)
+	}
+	{
+		if err := v.Alt.UnmarshalPostcard(d); err != nil {
+			return err
+		}
+	}
+	{
+		var x39 postcard.Duration
+		if err := x39.UnmarshalPostcard(d); err != nil {
+			return err
+		}
+		v.Took = time.Duration(x39)
+	}
+	{
+		var x40 postcard.UnixMillis
+		if err := x40.UnmarshalPostcard(d); err != nil {
+			return err
+		}
+		v.Stamp = time.Time(x40)
+	}
+	{
+		x41, err := d.DeserializeComplex128()
+		if err != nil {
+			return err
+		}
+		v.Phase = x41
+	}
+	{
+		n42, err := d.DeserializeUint()
+		if err != nil {
+			return err
+		}
+		if err := d.CheckLen(n42, 1); err != nil {
+			return err
+		}
+		v.IQ = make([]complex64, 0, d.PreallocLen(n42))
+		for i43 := uint(0); i43 < n42; i43++ {
+			var e44 complex64
+			x45, err := d.DeserializeComplex64()
+			if err != nil {
+				return err
+			}
+			e44 = x45
+			v.IQ = append(v.IQ, e44)
+		}
+	}
+	{
+		x46, err := d.DeserializeAddrPort()
+		if err != nil {
+			return err
+		}
+		v.Peer = x46
+	}
+	{
+		x47, err := d.DeserializePrefix()
+		if err != nil {
+			return err
+		}
+		v.Subnet = x47
+	}
+	{
+		if err := v.Owner.UnmarshalPostcard(d); err != nil {
+			return err
+		}
+	}
+	if err := d.DeserializeByteArray(v.Mac[:]); err != nil {
+		return err
+	}
+	{
+		n48, err := d.DeserializeBoundedLen(4)
+		if err != nil {
+			return err
+		}
+		if err := d.CheckLen(n48, 1); err != nil {
+			return err
+		}
+		v.Recent = make([]uint16, 0, d.PreallocLen(n48))
+		for i49 := uint(0); i49 < n48; i49++ {
+			var e50 uint16
+			x51, err := d.DeserializeUint16()
+			if err != nil {
+				return err
+			}
+			e50 = x51
+			v.Recent = append(v.Recent, e50)
+		}
+	}
+	{
+		if err := v.Burst.UnmarshalPostcard(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SizePostcard returns the encoded size of v.
+func (v Frame) SizePostcard() int {
+	n := 0
+	n += postcard.SizeOfUint(uint64(v.ID))
+	n += postcard.SizeOfUint(uint64(len(v.Readings)))
+	for i52 := range v.Readings {
+		n += v.Readings[i52].SizePostcard()
+	}
+	for i53 := range v.Window {
+		n += postcard.SizeOfInt(int64(v.Window[i53]))
+	}
+	n += 16
+	n += postcard.SizeOfUint(uint64(len(v.Flags)))
+	n += 1 * len(v.Flags)
+	n += postcard.SizeOfUint(uint64(len(v.Tags)))
+	for k54, e55 := range v.Tags {
+		n += postcard.SizeOfString(k54)
+		n += postcard.SizeOfUint(uint64(e55))
+	}
+	n += postcard.SizeOfInt(int64(v.Inner.Lo))
+	n += postcard.SizeOfInt(int64(v.Inner.Hi))
+	n += 4
+	n += v.Offset.SizePostcard()
+	n += postcard.Char(v.Key).SizePostcard()
+	n += v.Alt.SizePostcard()
+	n += postcard.Duration(v.Took).SizePostcard()
+	n += postcard.UnixMillis(v.Stamp).SizePostcard()
+	n += 16
+	n += postcard.SizeOfUint(uint64(len(v.IQ)))
+	n += 8 * len(v.IQ)
+	n += postcard.SizeOf(v.Peer)
+	n += postcard.SizeOf(v.Subnet)
+	n += v.Owner.SizePostcard()
+	n += 7
+	n += postcard.SizeOfUint(uint64(len(v.Recent)))
+	for i56 := range v.Recent {
+		n += postcard.SizeOfUint(uint64(v.Recent[i56]))
+	}
+	n += postcard.SizeOf(v.Burst)
+	return n
+}
+
+// MarshalPostcard encodes v without reflection.
+func (v Bounded) MarshalPostcard(s *postcard.Serializer) error {
+	if err := postcard.CheckMax(len(v.Recent), 4); err != nil {
+		return err
+	}
+	if err := s.SerializeUint(uint(len(v.Recent))); err != nil {
+		return err
+	}
+	for i57 := range v.Recent {
+		if err := s.SerializeUint16(v.Recent[i57]); err != nil {
+			return err
+		}
+	}
+	if err := postcard.CheckMax(len(v.Label), 200); err != nil {
+		return err
+	}
+	if err := s.SerializeString(v.Label); err != nil {
+		return err
+	}
+	if err := postcard.CheckMax(len(v.Raw), 4); err != nil {
+		return err
+	}
+	if err := s.SerializeBytes(v.Raw); err != nil {
+		return err
+	}
+	if err := postcard.CheckMax(len(v.Codes), 4); err != nil {
+		return err
+	}
+	if err := s.SerializeUint(uint(len(v.Codes))); err != nil {
+		return err
+	}
+	for k58, e59 := range v.Codes {
+		if err := s.SerializeUint8(k58); err != nil {
+			return err
+		}
+		if err := s.SerializeUint8(e59); err != nil {
+			return err
+		}
+	}
+	if v.Note.Valid {
+		if err := postcard.CheckMax(len(v.Note.Value), 4); err != nil {
+			return err
+		}
+	}
+	if err := v.Note.MarshalPostcard(s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalPostcard decodes v without reflection.
+func (v *Bounded) UnmarshalPostcard(d *postcard.Deserializer) error {
+	{
+		n60, err := d.DeserializeBoundedLen(4)
+		if err != nil {
+			return err
+		}
+		if err := d.CheckLen(n60, 1); err != nil {
+			return err
+		}
+		v.Recent = make([]uint16, 0, d.PreallocLen(n60))
+		for i61 := uint(0); i61 < n60; i61++ {
+			var e62 uint16
+			x63, err := d.DeserializeUint16()
+			if err != nil {
+				return err
+			}
+			e62 = x63
+			v.Recent = append(v.Recent, e62)
+		}
+	}
+	{
+		x64, err := d.DeserializeBoundedString(200)
+		if err != nil {
+			return err
+		}
+		v.Label = x64
+	}
+	{
+		x65, err := d.DeserializeBoundedBytes(4)
+		if err != nil {
+			return err
+		}
+		v.Raw = x65
+	}
+	{
+		n66, err := d.DeserializeBoundedLen(4)
+		if err != nil {
+			return err
+		}
+		if err := d.CheckLen(n66, 2); err != nil {
+			return err
+		}
+		if v.Codes == nil {
+			v.Codes = make(map[uint8]uint8)
+		}
+		for i67 := uint(0); i67 < n66; i67++ {
+			var k68 uint8
+			x70, err := d.DeserializeUint8()
+			if err != nil {
+				return err
+			}
+			k68 = x70
+			var e69 uint8
+			x71, err := d.DeserializeUint8()
+			if err != nil {
+				return err
+			}
+			e69 = x71
+			v.Codes[k68] = e69
+		}
+	}
+	{
+		if err := d.DeserializeBounded(&v.Note, 4); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SizePostcard returns the encoded size of v.
+func (v Bounded) SizePostcard() int {
+	n := 0
+	n += postcard.SizeOfUint(uint64(len(v.Recent)))
+	for i72 := range v.Recent {
+		n += postcard.SizeOfUint(uint64(v.Recent[i72]))
+	}
+	n += postcard.SizeOfString(v.Label)
+	n += postcard.SizeOfBytes(v.Raw)
+	n += postcard.SizeOfUint(uint64(len(v.Codes)))
+	n += 2 * len(v.Codes)
+	n += postcard.SizeOf(v.Note)
+	return n
+}
+
+// MarshalPostcard encodes v without reflection.
+func (v Samples) MarshalPostcard(s *postcard.Serializer) error {
+	if err := s.SerializeUint(uint(len(v))); err != nil {
+		return err
+	}
+	for i73 := range v {
+		if err := s.SerializeInt(v[i73]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalPostcard decodes v without reflection.
+func (v *Samples) UnmarshalPostcard(d *postcard.Deserializer) error {
+	n74, err := d.DeserializeUint()
+	if err != nil {
+		return err
+	}
+	if err := d.CheckLen(n74, 1); err != nil {
+		return err
+	}
+	(*v) = make(Samples, 0, d.PreallocLen(n74))
+	for i75 := uint(0); i75 < n74; i75++ {
+		var e76 int
+		x77, err := d.DeserializeInt()
+		if err != nil {
+			return err
+		}
+		e76 = x77
+		(*v) = append((*v), e76)
+	}
+	return nil
+}
+
+// SizePostcard returns the encoded size of v.
+func (v Samples) SizePostcard() int {
+	n := 0
+	n += postcard.SizeOfUint(uint64(len(v)))
+	for i78 := range v {
+		n += postcard.SizeOfInt(int64(v[i78]))
+	}
+	return n
+}
+
+// MarshalPostcard encodes v without reflection.
+func (v Ticks) MarshalPostcard(s *postcard.Serializer) error {
+	if err := s.SerializeUint(uint(len(v))); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalPostcard decodes v without reflection.
+func (v *Ticks) UnmarshalPostcard(d *postcard.Deserializer) error {
+	n80, err := d.DeserializeUint()
+	if err != nil {
+		return err
+	}
+	if err := d.CheckLen(n80, 0); err != nil {
+		return err
+	}
+	(*v) = make(Ticks, 0, d.PreallocLen(n80))
+	for i81 := uint(0); i81 < n80; i81++ {
+		var e82 postcard.Unit
+		(*v) = append((*v), e82)
+	}
+	return nil
+}
+
+// SizePostcard returns the encoded size of v.
+func (v Ticks) SizePostcard() int {
+	n := 0
+	n += postcard.SizeOfUint(uint64(len(v)))
+	return n
+}
@@ -0,0 +1,282 @@
+// Code generated by postcard-gen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"bytes"
+	"math/rand"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+func TestReadingPostcard(t *testing.T) {
+	type plain Reading
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var v Reading
+		postcardGenFill(reflect.ValueOf(&v).Elem(), r)
+
+		s := postcard.NewSerializer(nil)
+		if err := v.MarshalPostcard(s); err != nil {
+			t.Fatalf("MarshalPostcard(%+v) error = %v", v, err)
+		}
+		got, _ := s.Result()
+		want, err := postcard.Serialize(plain(v))
+		if err != nil {
+			t.Fatalf("Serialize(%+v) error = %v", v, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("MarshalPostcard(%+v) = %v, want %v", v, got, want)
+		}
+		if n := v.SizePostcard(); n != len(got) {
+			t.Fatalf("SizePostcard(%+v) = %d, want %d", v, n, len(got))
+		}
+
+		var decoded Reading
+		if err := decoded.UnmarshalPostcard(postcard.NewDeserializer(got)); err != nil {
+			t.Fatalf("UnmarshalPostcard(%v) error = %v", got, err)
+		}
+		var ref plain
+		if err := postcard.Deserialize(got, &ref); err != nil {
+			t.Fatalf("Deserialize(%v) error = %v", got, err)
+		}
+		if !reflect.DeepEqual(decoded, Reading(ref)) {
+			t.Fatalf("UnmarshalPostcard(%v) = %+v, want %+v", got, decoded, ref)
+		}
+	}
+}
+
+func TestFramePostcard(t *testing.T) {
+	type plain Frame
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var v Frame
+		postcardGenFill(reflect.ValueOf(&v).Elem(), r)
+
+		s := postcard.NewSerializer(nil)
+		if err := v.MarshalPostcard(s); err != nil {
+			t.Fatalf("MarshalPostcard(%+v) error = %v", v, err)
+		}
+		got, _ := s.Result()
+		want, err := postcard.Serialize(plain(v))
+		if err != nil {
+			t.Fatalf("Serialize(%+v) error = %v", v, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("MarshalPostcard(%+v) = %v, want %v", v, got, want)
+		}
+		if n := v.SizePostcard(); n != len(got) {
+			t.Fatalf("SizePostcard(%+v) = %d, want %d", v, n, len(got))
+		}
+
+		var decoded Frame
+		if err := decoded.UnmarshalPostcard(postcard.NewDeserializer(got)); err != nil {
+			t.Fatalf("UnmarshalPostcard(%v) error = %v", got, err)
+		}
+		var ref plain
+		if err := postcard.Deserialize(got, &ref); err != nil {
+			t.Fatalf("Deserialize(%v) error = %v", got, err)
+		}
+		if !reflect.DeepEqual(decoded, Frame(ref)) {
+			t.Fatalf("UnmarshalPostcard(%v) = %+v, want %+v", got, decoded, ref)
+		}
+	}
+}
+
+func TestBoundedPostcard(t *testing.T) {
+	type plain Bounded
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var v Bounded
+		postcardGenFill(reflect.ValueOf(&v).Elem(), r)
+
+		s := postcard.NewSerializer(nil)
+		if err := v.MarshalPostcard(s); err != nil {
+			t.Fatalf("MarshalPostcard(%+v) error = %v", v, err)
+		}
+		got, _ := s.Result()
+		want, err := postcard.Serialize(plain(v))
+		if err != nil {
+			t.Fatalf("Serialize(%+v) error = %v", v, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("MarshalPostcard(%+v) = %v, want %v", v, got, want)
+		}
+		if n := v.SizePostcard(); n != len(got) {
+			t.Fatalf("SizePostcard(%+v) = %d, want %d", v, n, len(got))
+		}
+
+		var decoded Bounded
+		if err := decoded.UnmarshalPostcard(postcard.NewDeserializer(got)); err != nil {
+			t.Fatalf("UnmarshalPostcard(%v) error = %v", got, err)
+		}
+		var ref plain
+		if err := postcard.Deserialize(got, &ref); err != nil {
+			t.Fatalf("Deserialize(%v) error = %v", got, err)
+		}
+		if !reflect.DeepEqual(decoded, Bounded(ref)) {
+			t.Fatalf("UnmarshalPostcard(%v) = %+v, want %+v", got, decoded, ref)
+		}
+	}
+}
+
+func TestSamplesPostcard(t *testing.T) {
+	type plain Samples
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var v Samples
+		postcardGenFill(reflect.ValueOf(&v).Elem(), r)
+
+		s := postcard.NewSerializer(nil)
+		if err := v.MarshalPostcard(s); err != nil {
+			t.Fatalf("MarshalPostcard(%+v) error = %v", v, err)
+		}
+		got, _ := s.Result()
+		want, err := postcard.Serialize(plain(v))
+		if err != nil {
+			t.Fatalf("Serialize(%+v) error = %v", v, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("MarshalPostcard(%+v) = %v, want %v", v, got, want)
+		}
+		if n := v.SizePostcard(); n != len(got) {
+			t.Fatalf("SizePostcard(%+v) = %d, want %d", v, n, len(got))
+		}
+
+		var decoded Samples
+		if err := decoded.UnmarshalPostcard(postcard.NewDeserializer(got)); err != nil {
+			t.Fatalf("UnmarshalPostcard(%v) error = %v", got, err)
+		}
+		var ref plain
+		if err := postcard.Deserialize(got, &ref); err != nil {
+			t.Fatalf("Deserialize(%v) error = %v", got, err)
+		}
+		if !reflect.DeepEqual(decoded, Samples(ref)) {
+			t.Fatalf("UnmarshalPostcard(%v) = %+v, want %+v", got, decoded, ref)
+		}
+	}
+}
+
+func TestTicksPostcard(t *testing.T) {
+	type plain Ticks
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		var v Ticks
+		postcardGenFill(reflect.ValueOf(&v).Elem(), r)
+
+		s := postcard.NewSerializer(nil)
+		if err := v.MarshalPostcard(s); err != nil {
+			t.Fatalf("MarshalPostcard(%+v) error = %v", v, err)
+		}
+		got, _ := s.Result()
+		want, err := postcard.Serialize(plain(v))
+		if err != nil {
+			t.Fatalf("Serialize(%+v) error = %v", v, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("MarshalPostcard(%+v) = %v, want %v", v, got, want)
+		}
+		if n := v.SizePostcard(); n != len(got) {
+			t.Fatalf("SizePostcard(%+v) = %d, want %d", v, n, len(got))
+		}
+
+		var decoded Ticks
+		if err := decoded.UnmarshalPostcard(postcard.NewDeserializer(got)); err != nil {
+			t.Fatalf("UnmarshalPostcard(%v) error = %v", got, err)
+		}
+		var ref plain
+		if err := postcard.Deserialize(got, &ref); err != nil {
+			t.Fatalf("Deserialize(%v) error = %v", got, err)
+		}
+		if !reflect.DeepEqual(decoded, Ticks(ref)) {
+			t.Fatalf("UnmarshalPostcard(%v) = %+v, want %+v", got, decoded, ref)
+		}
+	}
+}
+
+// postcardGenFill sets the exported parts of v to random values.
+func postcardGenFill(v reflect.Value, r *rand.Rand) {
+	switch v.Type() {
+	case reflect.TypeOf(postcard.Char(0)):
+		postcardGenRune(v, r)
+		return
+	case reflect.TypeOf(time.Duration(0)), reflect.TypeOf(postcard.Duration(0)):
+		v.SetInt(r.Int63())
+		return
+	case reflect.TypeOf(time.Time{}):
+		v.Set(reflect.ValueOf(time.Unix(r.Int63n(1<<33), 0).UTC()))
+		return
+	case reflect.TypeOf(netip.Addr{}):
+		v.Set(reflect.ValueOf(postcardGenAddr(r)))
+		return
+	case reflect.TypeOf(netip.AddrPort{}):
+		v.Set(reflect.ValueOf(netip.AddrPortFrom(postcardGenAddr(r), uint16(r.Intn(1<<16)))))
+		return
+	case reflect.TypeOf(netip.Prefix{}):
+		a := postcardGenAddr(r)
+		v.Set(reflect.ValueOf(netip.PrefixFrom(a, r.Intn(a.BitLen()+1))))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			switch {
+			case !f.IsExported():
+			case strings.Contains(f.Tag.Get("postcard"), "char"):
+				postcardGenRune(v.Field(i), r)
+			default:
+				postcardGenFill(v.Field(i), r)
+			}
+		}
+	case reflect.Slice:
+		n := r.Intn(4)
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			postcardGenFill(v.Index(i), r)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			postcardGenFill(v.Index(i), r)
+		}
+	case reflect.Map:
+		n := r.Intn(4)
+		v.Set(reflect.MakeMapWithSize(v.Type(), n))
+		for i := 0; i < n; i++ {
+			k := reflect.New(v.Type().Key()).Elem()
+			postcardGenFill(k, r)
+			e := reflect.New(v.Type().Elem()).Elem()
+			postcardGenFill(e, r)
+			v.SetMapIndex(k, e)
+		}
+	default:
+		if x, ok := quick.Value(v.Type(), r); ok {
+			v.Set(x)
+		}
+	}
+}
+
+// postcardGenAddr returns a random IPv4 or IPv6 address.
+func postcardGenAddr(r *rand.Rand) netip.Addr {
+	var b [16]byte
+	r.Read(b[:])
+	if r.Intn(2) == 0 {
+		return netip.AddrFrom4([4]byte(b[:4]))
+	}
+	return netip.AddrFrom16(b)
+}
+
+// postcardGenRune sets v to a random Unicode scalar value.
+func postcardGenRune(v reflect.Value, r *rand.Rand) {
+	c := r.Int63n(0x10FFFF - 0x800)
+	if c >= 0xD800 {
+		c += 0x800
+	}
+	v.SetInt(c)
+}
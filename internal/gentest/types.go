@@ -0,0 +1,71 @@
+// Package gentest holds types used to exercise postcard-gen. The generated
+// files are checked in and kept current by cmd/postcard-gen's tests.
+package gentest
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+//go:generate go run ../../cmd/postcard-gen
+
+type Mode uint8
+
+//postcard:generate
+type Reading struct {
+	Channel uint16
+	Mode    Mode
+	Value   float32
+	Delta   int32
+	Valid   bool
+	Label   string
+	Raw     []byte
+	Count   postcard.Varint
+	seq     int
+}
+
+//postcard:generate
+type Frame struct {
+	ID       uint32
+	Readings []Reading
+	Window   [4]int16
+	Calib    [2]float64
+	Flags    []bool
+	Tags     map[string]uint64
+	Inner    struct {
+		Lo, Hi int64
+	}
+	Addr   uint32 `postcard:"fixint=be"`
+	Offset postcard.FixI16LE
+	Key    rune `postcard:"char"`
+	Alt    postcard.Char
+	Took   time.Duration
+	Stamp  time.Time `postcard:"unix=ms"`
+	Phase  complex128
+	IQ     []complex64
+	Peer   netip.AddrPort
+	Subnet netip.Prefix
+	Owner  postcard.UUID
+	Mac    [6]byte  `postcard:"bytes"`
+	Recent []uint16 `postcard:"max=4"`
+	Burst  postcard.Vec[int16, postcard.N4]
+}
+
+//postcard:generate
+type Bounded struct {
+	Recent []uint16                `postcard:"max=4"`
+	Label  string                  `postcard:"max=200"`
+	Raw    []byte                  `postcard:"max=4"`
+	Codes  map[uint8]uint8         `postcard:"max=4"`
+	Note   postcard.Option[[]byte] `postcard:"max=4"`
+}
+
+//postcard:generate
+type Samples []int
+
+// Ticks holds elements that encode in no bytes, so only its length is sent.
+//
+//postcard:generate
+type Ticks []postcard.Unit
@@ -0,0 +1,133 @@
+// Package load type-checks a Go package from source and finds the types
+// marked for postcard code generation.
+package load
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Directive marks a type declaration for code generation. It must appear on
+// its own line in the doc comment of the type.
+const Directive = "//postcard:generate"
+
+type Package struct {
+	Fset  *token.FileSet
+	Types *types.Package
+	Files []*ast.File
+	Info  *types.Info
+	// Marked lists the types carrying Directive, in source order.
+	Marked []*types.TypeName
+}
+
+// Dir loads the package in dir. Files whose base name is listed in skip are
+// ignored, so stale generated output never breaks type checking.
+func Dir(dir string, skip ...string) (*Package, error) {
+	fset := token.NewFileSet()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if contains(skip, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	var files []*ast.File
+	for _, name := range names {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	info := &types.Info{
+		Types:     make(map[ast.Expr]types.TypeAndValue),
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Instances: make(map[*ast.Ident]types.Instance),
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check(files[0].Name.Name, fset, files, info)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Package{Fset: fset, Types: pkg, Files: files, Info: info}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				if !hasDirective(doc) {
+					continue
+				}
+				obj, ok := pkg.Scope().Lookup(ts.Name.Name).(*types.TypeName)
+				if !ok {
+					continue
+				}
+				p.Marked = append(p.Marked, obj)
+			}
+		}
+	}
+	return p, nil
+}
+
+// IsMarked reports whether obj carries Directive.
+func (p *Package) IsMarked(obj *types.TypeName) bool {
+	for _, m := range p.Marked {
+		if m == obj {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == Directive {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+// Code generated by postcard-rs2go from device.rs. DO NOT EDIT.
+
+package rs2gotest
+
+import (
+	"net/netip"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+type Register = uint32
+
+type Mode uint32
+
+const (
+	ModeIdle Mode = iota
+	ModeSampling
+	ModeFault
+)
+
+func (Mode) PostcardSchema() (*postcard.Schema, error) {
+	return postcard.UnitEnumSchema("Mode", "Idle", "Sampling", "Fault"), nil
+}
+
+type Meters float32
+
+func (Meters) PostcardSchema() (*postcard.Schema, error) {
+	return postcard.NewtypeStructSchema[float32]("Meters")
+}
+
+type Point struct {
+	Field0 int16
+	Field1 int16
+}
+
+func (Point) PostcardSchema() (*postcard.Schema, error) {
+	return postcard.TupleStructSchema[Point]("Point")
+}
+
+type Heartbeat struct{}
+
+type RegisterWrite struct {
+	Addr  Register `postcard:"fixint=le"`
+	Value uint16   `postcard:"fixint=be"`
+}
+
+type Status struct {
+	DeviceID uint32
+	Name     string `postcard:"max=16"`
+	Label    string
+	Mode     Mode
+	Samples  []Meters `postcard:"max=8"`
+	Raw      []byte
+	Position postcard.Option[Point]
+	Window   [4]uint8
+	Counters map[uint8]uint64
+	UptimeNs postcard.Uint128
+	Unit     postcard.Char
+	Range    postcard.Tuple2[int16, int16]
+	Uptime   postcard.Duration
+	Iq       []complex64              `postcard:"max=8"`
+	Nickname postcard.Option[string]  `postcard:"max=8"`
+	History  postcard.Option[[]int16] `postcard:"max=4"`
+	Type     bool
+}
+
+type Command interface {
+	isCommand()
+}
+
+type CommandPing struct{}
+
+type CommandReset struct {
+	DelayMs uint32
+}
+
+type CommandWrite struct {
+	Value RegisterWrite
+}
+
+type CommandMove struct {
+	Field0 int16
+	Field1 int16
+}
+
+type CommandCalibrate struct {
+	Value postcard.Option[Meters]
+}
+
+func (CommandPing) isCommand()      {}
+func (CommandReset) isCommand()     {}
+func (CommandWrite) isCommand()     {}
+func (CommandMove) isCommand()      {}
+func (CommandCalibrate) isCommand() {}
+
+func (CommandPing) PostcardVariant() (postcard.SchemaVariant, error) {
+	return postcard.UnitVariantSchema("Ping"), nil
+}
+
+func (CommandReset) PostcardVariant() (postcard.SchemaVariant, error) {
+	return postcard.StructVariantSchema[CommandReset]("Reset")
+}
+
+func (CommandWrite) PostcardVariant() (postcard.SchemaVariant, error) {
+	return postcard.NewtypeVariantSchema[RegisterWrite]("Write")
+}
+
+func (CommandMove) PostcardVariant() (postcard.SchemaVariant, error) {
+	return postcard.TupleVariantSchema[CommandMove]("Move")
+}
+
+func (CommandCalibrate) PostcardVariant() (postcard.SchemaVariant, error) {
+	return postcard.NewtypeVariantSchema[postcard.Option[Meters]]("Calibrate")
+}
+
+type Reply struct {
+	Field0 postcard.Option[Command]
+}
+
+func (Reply) PostcardSchema() (*postcard.Schema, error) {
+	return postcard.NewtypeStructSchema[postcard.Option[Command]]("Reply")
+}
+
+type Network struct {
+	Gateway netip.Addr
+	Server  netip.AddrPort
+	Subnet  netip.Prefix
+	Mask    [4]byte
+	ID      postcard.UUID
+	Mac     [6]uint8 `postcard:"bytes"`
+}
+
+type Ack struct {
+	Field0 postcard.Result[postcard.Unit, Mode]
+}
+
+func (Ack) PostcardSchema() (*postcard.Schema, error) {
+	return postcard.NewtypeStructSchema[postcard.Result[postcard.Unit, Mode]]("Ack")
+}
+
+func init() {
+	postcard.RegisterEnum[Command](CommandPing{}, CommandReset{}, CommandWrite{}, CommandMove{}, CommandCalibrate{})
+}
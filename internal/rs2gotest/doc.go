@@ -0,0 +1,6 @@
+// Package rs2gotest holds the Go that postcard-rs2go writes for
+// cmd/postcard-rs2go/testdata/device.rs. The generated file is checked in
+// and kept current by cmd/postcard-rs2go's tests.
+package rs2gotest
+
+//go:generate go run ../../cmd/postcard-rs2go -pkg rs2gotest -o device.go ../../cmd/postcard-rs2go/testdata/device.rs
@@ -0,0 +1,158 @@
+// Package netrpc implements postcard codecs for the standard library's
+// net/rpc, a compact alternative to gob for Go-to-Go services. It mirrors
+// net/rpc/jsonrpc.
+//
+// Each request and response is one length-prefixed frame holding a
+// postcard-encoded header followed by the postcard-encoded body.
+package netrpc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+
+	"github.com/yixinin/postcard-go/postcard"
+	prpc "github.com/yixinin/postcard-go/rpc"
+)
+
+type requestHeader struct {
+	ServiceMethod string
+	Seq           uint64
+}
+
+type responseHeader struct {
+	ServiceMethod string
+	Seq           uint64
+	Error         string
+}
+
+// conn reads and writes header and body frames.
+type conn struct {
+	t    prpc.Transport
+	body []byte
+}
+
+func (c *conn) write(header, body interface{}) error {
+	s := postcard.NewSerializer(nil)
+	if err := s.SerializeValue(header); err != nil {
+		return err
+	}
+	if err := s.SerializeValue(body); err != nil {
+		return err
+	}
+	frame, err := s.Result()
+	if err != nil {
+		return err
+	}
+	return c.t.Send(frame)
+}
+
+// readHeader receives a frame, decodes its header into h and keeps the body
+// for readBody.
+func (c *conn) readHeader(h interface{}) error {
+	frame, err := c.t.Recv()
+	if err != nil {
+		if errors.Is(err, net.ErrClosed) || err == io.ErrClosedPipe {
+			err = io.EOF
+		}
+		return err
+	}
+	d := postcard.NewDeserializer(frame)
+	if err := d.DeserializeValue(h); err != nil {
+		return err
+	}
+	c.body = d.Remaining()
+	return nil
+}
+
+// readBody decodes the body kept by readHeader into v, or discards it if v
+// is nil.
+func (c *conn) readBody(v interface{}) error {
+	body := c.body
+	c.body = nil
+	if v == nil {
+		return nil
+	}
+	return postcard.Deserialize(body, v)
+}
+
+type clientCodec struct {
+	conn
+}
+
+// NewClientCodec returns a postcard rpc.ClientCodec on rwc.
+func NewClientCodec(rwc io.ReadWriteCloser) rpc.ClientCodec {
+	return &clientCodec{conn{t: prpc.NewStreamTransport(rwc)}}
+}
+
+func (c *clientCodec) WriteRequest(r *rpc.Request, body interface{}) error {
+	return c.write(requestHeader{ServiceMethod: r.ServiceMethod, Seq: r.Seq}, body)
+}
+
+func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
+	var h responseHeader
+	if err := c.readHeader(&h); err != nil {
+		return err
+	}
+	r.ServiceMethod, r.Seq, r.Error = h.ServiceMethod, h.Seq, h.Error
+	return nil
+}
+
+func (c *clientCodec) ReadResponseBody(body interface{}) error {
+	return c.readBody(body)
+}
+
+func (c *clientCodec) Close() error {
+	return c.t.Close()
+}
+
+type serverCodec struct {
+	conn
+}
+
+// NewServerCodec returns a postcard rpc.ServerCodec on rwc.
+func NewServerCodec(rwc io.ReadWriteCloser) rpc.ServerCodec {
+	return &serverCodec{conn{t: prpc.NewStreamTransport(rwc)}}
+}
+
+func (c *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	var h requestHeader
+	if err := c.readHeader(&h); err != nil {
+		return err
+	}
+	r.ServiceMethod, r.Seq = h.ServiceMethod, h.Seq
+	return nil
+}
+
+func (c *serverCodec) ReadRequestBody(body interface{}) error {
+	return c.readBody(body)
+}
+
+func (c *serverCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	return c.write(responseHeader{ServiceMethod: r.ServiceMethod, Seq: r.Seq, Error: r.Error}, body)
+}
+
+func (c *serverCodec) Close() error {
+	return c.t.Close()
+}
+
+// NewClient returns an rpc.Client using postcard on conn.
+func NewClient(conn io.ReadWriteCloser) *rpc.Client {
+	return rpc.NewClientWithCodec(NewClientCodec(conn))
+}
+
+// Dial connects to a postcard net/rpc server at address.
+func Dial(network, address string) (*rpc.Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// ServeConn serves a single connection with the default rpc server and
+// blocks until the client hangs up.
+func ServeConn(conn io.ReadWriteCloser) {
+	rpc.ServeCodec(NewServerCodec(conn))
+}
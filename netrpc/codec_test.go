@@ -0,0 +1,97 @@
+package netrpc
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"sync"
+	"testing"
+)
+
+type Args struct {
+	A, B int32
+}
+
+type Quotient struct {
+	Quo, Rem int32
+	Note     string
+}
+
+type Arith int
+
+func (Arith) Multiply(args *Args, reply *int64) error {
+	*reply = int64(args.A) * int64(args.B)
+	return nil
+}
+
+func (Arith) Divide(args *Args, reply *Quotient) error {
+	if args.B == 0 {
+		return errors.New("divide by zero")
+	}
+	*reply = Quotient{Quo: args.A / args.B, Rem: args.A % args.B, Note: "ok"}
+	return nil
+}
+
+func newPipeClient(t *testing.T) *rpc.Client {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.Register(new(Arith)); err != nil {
+		t.Fatal(err)
+	}
+	cli, srv := net.Pipe()
+	go server.ServeCodec(NewServerCodec(srv))
+	client := NewClient(cli)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestCodec(t *testing.T) {
+	client := newPipeClient(t)
+
+	var product int64
+	if err := client.Call("Arith.Multiply", &Args{A: 7, B: -8}, &product); err != nil || product != -56 {
+		t.Errorf("Multiply = %d, %v", product, err)
+	}
+	var q Quotient
+	if err := client.Call("Arith.Divide", &Args{A: 17, B: 5}, &q); err != nil || q != (Quotient{3, 2, "ok"}) {
+		t.Errorf("Divide = %+v, %v", q, err)
+	}
+
+	err := client.Call("Arith.Divide", &Args{A: 1}, &q)
+	var serverErr rpc.ServerError
+	if !errors.As(err, &serverErr) || serverErr != "divide by zero" {
+		t.Errorf("Divide by zero error = %v", err)
+	}
+	if err := client.Call("Arith.Missing", &Args{}, &q); err == nil {
+		t.Error("unknown method succeeded")
+	}
+	// The connection survives the failures.
+	if err := client.Call("Arith.Multiply", &Args{A: 2, B: 3}, &product); err != nil || product != 6 {
+		t.Errorf("Multiply after errors = %d, %v", product, err)
+	}
+}
+
+func TestCodecConcurrent(t *testing.T) {
+	client := newPipeClient(t)
+	var wg sync.WaitGroup
+	for i := int32(0); i < 50; i++ {
+		wg.Add(1)
+		go func(i int32) {
+			defer wg.Done()
+			var product int64
+			if err := client.Call("Arith.Multiply", &Args{A: i, B: i}, &product); err != nil || product != int64(i*i) {
+				t.Errorf("Multiply(%d) = %d, %v", i, product, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestCodecClose(t *testing.T) {
+	client := newPipeClient(t)
+	client.Close()
+	var product int64
+	if err := client.Call("Arith.Multiply", &Args{A: 1, B: 1}, &product); !errors.Is(err, rpc.ErrShutdown) {
+		t.Errorf("Call after Close error = %v", err)
+	}
+}
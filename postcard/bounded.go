@@ -0,0 +1,230 @@
+package postcard
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Bounded collections mirror heapless::Vec<T, N>, heapless::String<N> and
+// heapless::LinearMap<K, V, N>, which hold at most N elements (bytes, for a
+// String) and fail to decode anything longer. A slice, string or map field
+// tagged `postcard:"max=N"` is bounded that way, as is the value of an
+// Option field so tagged, and Vec and String carry the bound in their type.
+// Encoding or decoding more than N elements fails with ErrCapacityExceeded;
+// otherwise the encoding is that of an unbounded slice, string or map.
+// MaxSizeOf uses the bounds too.
+
+// Capacity is the N of a Vec or String. N1 to N1024 cover the powers of two;
+// other capacities are types of their own:
+//
+//	type N20 struct{}
+//
+//	func (N20) Capacity() int { return 20 }
+type Capacity interface {
+	Capacity() int
+}
+
+type (
+	N1    struct{}
+	N2    struct{}
+	N4    struct{}
+	N8    struct{}
+	N16   struct{}
+	N32   struct{}
+	N64   struct{}
+	N128  struct{}
+	N256  struct{}
+	N512  struct{}
+	N1024 struct{}
+)
+
+func (N1) Capacity() int    { return 1 }
+func (N2) Capacity() int    { return 2 }
+func (N4) Capacity() int    { return 4 }
+func (N8) Capacity() int    { return 8 }
+func (N16) Capacity() int   { return 16 }
+func (N32) Capacity() int   { return 32 }
+func (N64) Capacity() int   { return 64 }
+func (N128) Capacity() int  { return 128 }
+func (N256) Capacity() int  { return 256 }
+func (N512) Capacity() int  { return 512 }
+func (N1024) Capacity() int { return 1024 }
+
+// Vec is a slice of at most N elements, like heapless::Vec<T, N>.
+type Vec[T any, N Capacity] []T
+
+// String is a string of at most N bytes, like heapless::String<N>.
+type String[N Capacity] string
+
+var boundedType = reflect.TypeOf((*interface{ maxLen() int })(nil)).Elem()
+
+func capacity[N Capacity]() int {
+	var n N
+	return n.Capacity()
+}
+
+func (v Vec[T, N]) MarshalPostcard(s *Serializer) error {
+	return s.serializeBounded(reflect.ValueOf([]T(v)), capacity[N]())
+}
+
+func (v *Vec[T, N]) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeBounded(reflect.ValueOf((*[]T)(v)).Elem(), capacity[N]())
+}
+
+func (Vec[T, N]) maxLen() int {
+	return capacity[N]()
+}
+
+func (v String[N]) MarshalPostcard(s *Serializer) error {
+	if err := CheckMax(len(v), capacity[N]()); err != nil {
+		return err
+	}
+	return s.SerializeString(string(v))
+}
+
+func (v *String[N]) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeBounded(reflect.ValueOf((*string)(v)).Elem(), capacity[N]())
+}
+
+func (String[N]) maxLen() int {
+	return capacity[N]()
+}
+
+// CheckMax returns an error wrapping ErrCapacityExceeded if n, the length of
+// a slice, string or map, is more than max. Code generated by postcard-gen
+// calls it for fields tagged `postcard:"max=N"`.
+func CheckMax(n, max int) error {
+	if n > max {
+		return fmt.Errorf("%w: length %d, max %d", ErrCapacityExceeded, n, max)
+	}
+	return nil
+}
+
+// DeserializeBoundedLen reads the length prefix of a slice, string or map of
+// at most max elements, failing with ErrCapacityExceeded before anything is
+// allocated if it is longer. Code generated by postcard-gen calls it and the
+// other DeserializeBounded methods for fields tagged `postcard:"max=N"`.
+func (d *Deserializer) DeserializeBoundedLen(max int) (uint, error) {
+	sz, err := d.DeserializeUint()
+	if err != nil {
+		return 0, err
+	}
+	if sz > uint(max) {
+		return 0, fmt.Errorf("%w: length %d, max %d", ErrCapacityExceeded, sz, max)
+	}
+	return sz, nil
+}
+
+// DeserializeBoundedString reads a string of at most max bytes.
+func (d *Deserializer) DeserializeBoundedString(max int) (string, error) {
+	sz, err := d.DeserializeBoundedLen(max)
+	if err != nil {
+		return "", err
+	}
+	return d.takeString(sz)
+}
+
+// DeserializeBoundedBytes reads at most max length-prefixed bytes.
+func (d *Deserializer) DeserializeBoundedBytes(max int) ([]byte, error) {
+	sz, err := d.DeserializeBoundedLen(max)
+	if err != nil {
+		return nil, err
+	}
+	return d.takeBytes(int(sz))
+}
+
+// DeserializeBounded decodes into v, a pointer to a slice, string or map of
+// at most max elements or to an Option of one.
+func (d *Deserializer) DeserializeBounded(v interface{}, max int) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("expected pointer, got %T", v)
+	}
+	return d.deserializeBounded(rv.Elem(), max)
+}
+
+// serializeBounded encodes val, a slice, string or map of at most max
+// elements, or an Option of one.
+func (s *Serializer) serializeBounded(val reflect.Value, max int) error {
+	if val.Kind() == reflect.Ptr {
+		// Pointers encode as what they point to, and nil as None.
+		if val.IsNil() {
+			return s.SerializeOption(nil)
+		}
+		return s.serializeBounded(val.Elem(), max)
+	}
+	if val.Kind() == reflect.Struct && val.Type().Implements(optionType) {
+		if !val.FieldByName("Valid").Bool() {
+			return s.pushByte(0)
+		}
+		if err := s.pushByte(1); err != nil {
+			return err
+		}
+		return s.serializeBounded(val.FieldByName("Value"), max)
+	}
+	switch val.Kind() {
+	case reflect.Slice, reflect.String, reflect.Map:
+	default:
+		return fmt.Errorf("max needs a slice, string or map, got %v", val.Type())
+	}
+	if err := CheckMax(val.Len(), max); err != nil {
+		return err
+	}
+	return s.serializeReflect(val)
+}
+
+// deserializeBounded decodes into val, a slice, string or map of at most max
+// elements or an Option of one, failing before it reads the elements if the
+// length is longer.
+func (d *Deserializer) deserializeBounded(val reflect.Value, max int) error {
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+		return d.deserializeBounded(val.Elem(), max)
+	}
+	if val.Kind() == reflect.Struct && val.Type().Implements(optionType) {
+		b, err := d.popByte()
+		if err != nil {
+			return err
+		}
+		switch b {
+		case 0:
+			val.SetZero()
+			return nil
+		case 1:
+			val.FieldByName("Valid").SetBool(true)
+			return d.deserializeBounded(val.FieldByName("Value"), max)
+		default:
+			return ErrDeserializeBadOption
+		}
+	}
+	switch val.Kind() {
+	case reflect.Slice, reflect.String, reflect.Map:
+	default:
+		return fmt.Errorf("max needs a slice, string or map, got %v", val.Type())
+	}
+	sz, err := d.DeserializeBoundedLen(max)
+	if err != nil {
+		return err
+	}
+	switch {
+	case val.Kind() == reflect.String:
+		decoded, err := d.takeString(sz)
+		if err != nil {
+			return err
+		}
+		val.SetString(decoded)
+	case isByteSlice(val.Type()):
+		decoded, err := d.takeBytes(int(sz))
+		if err != nil {
+			return err
+		}
+		val.SetBytes(decoded)
+	case val.Kind() == reflect.Slice:
+		return d.deserializeSliceLen(val, sz)
+	default:
+		return d.deserializeMapLen(val, sz)
+	}
+	return nil
+}
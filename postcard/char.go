@@ -0,0 +1,66 @@
+package postcard
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Char is a Rust char: one Unicode scalar value, encoded as a length-prefixed
+// UTF-8 string. A plain rune encodes as an i32 varint instead; tag rune
+// fields `postcard:"char"` or declare them as Char to match a Rust char.
+type Char rune
+
+func (s *Serializer) SerializeChar(r rune) error {
+	if !utf8.ValidRune(r) {
+		return fmt.Errorf("invalid char %U", r)
+	}
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	return s.SerializeBytes(buf[:n])
+}
+
+// DeserializeChar reads a char, rejecting payloads that are not exactly one
+// valid scalar value, such as surrogates or several characters.
+func (d *Deserializer) DeserializeChar() (rune, error) {
+	sz, err := d.DeserializeUint()
+	if err != nil {
+		return 0, err
+	}
+	if sz == 0 || sz > utf8.UTFMax {
+		return 0, ErrDeserializeBadChar
+	}
+	bytes, err := d.takeBytes(int(sz))
+	if err != nil {
+		return 0, err
+	}
+	r, n := utf8.DecodeRune(bytes)
+	if r == utf8.RuneError && n <= 1 || n != len(bytes) {
+		return 0, ErrDeserializeBadChar
+	}
+	return r, nil
+}
+
+func (c Char) MarshalPostcard(s *Serializer) error {
+	return s.SerializeChar(rune(c))
+}
+
+func (c *Char) UnmarshalPostcard(d *Deserializer) error {
+	r, err := d.DeserializeChar()
+	if err != nil {
+		return err
+	}
+	*c = Char(r)
+	return nil
+}
+
+func (c Char) SizePostcard() int {
+	n := utf8.RuneLen(rune(c))
+	if n < 0 {
+		return 0
+	}
+	return SizeOfUint(uint64(n)) + n
+}
+
+func (Char) PostcardSchema() (*Schema, error) {
+	return &Schema{Name: "char", Kind: SchemaChar}, nil
+}
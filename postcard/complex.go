@@ -0,0 +1,120 @@
+package postcard
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+)
+
+// Complex numbers encode like num_complex::Complex<f32> and Complex<f64>: a
+// (re, im) tuple of little-endian floats.
+
+var (
+	complex64Type  = reflect.TypeOf(complex64(0))
+	complex128Type = reflect.TypeOf(complex128(0))
+)
+
+func (s *Serializer) SerializeComplex64(v complex64) error {
+	s.buf = append(s.buf, encodeFloat32LE(real(v))...)
+	s.buf = append(s.buf, encodeFloat32LE(imag(v))...)
+	return nil
+}
+
+func (s *Serializer) SerializeComplex128(v complex128) error {
+	s.buf = append(s.buf, encodeFloat64LE(real(v))...)
+	s.buf = append(s.buf, encodeFloat64LE(imag(v))...)
+	return nil
+}
+
+func (d *Deserializer) DeserializeComplex64() (complex64, error) {
+	re, err := decodeFloat32LE(d.data, &d.pos)
+	if err != nil {
+		return 0, err
+	}
+	im, err := decodeFloat32LE(d.data, &d.pos)
+	if err != nil {
+		return 0, err
+	}
+	return complex(re, im), nil
+}
+
+func (d *Deserializer) DeserializeComplex128() (complex128, error) {
+	re, err := decodeFloat64LE(d.data, &d.pos)
+	if err != nil {
+		return 0, err
+	}
+	im, err := decodeFloat64LE(d.data, &d.pos)
+	if err != nil {
+		return 0, err
+	}
+	return complex(re, im), nil
+}
+
+// serializeComplexSlice writes the elements of a slice of complex64 or
+// complex128 in one pass, without reflecting on each element. It reports
+// false for other slices.
+func (s *Serializer) serializeComplexSlice(val reflect.Value) bool {
+	switch val.Type().Elem() {
+	case complex64Type:
+		xs := val.Convert(reflect.TypeOf([]complex64(nil))).Interface().([]complex64)
+		buf := s.grow(8 * len(xs))
+		for i, x := range xs {
+			binary.LittleEndian.PutUint32(buf[8*i:], math.Float32bits(real(x)))
+			binary.LittleEndian.PutUint32(buf[8*i+4:], math.Float32bits(imag(x)))
+		}
+		return true
+	case complex128Type:
+		xs := val.Convert(reflect.TypeOf([]complex128(nil))).Interface().([]complex128)
+		buf := s.grow(16 * len(xs))
+		for i, x := range xs {
+			binary.LittleEndian.PutUint64(buf[16*i:], math.Float64bits(real(x)))
+			binary.LittleEndian.PutUint64(buf[16*i+8:], math.Float64bits(imag(x)))
+		}
+		return true
+	}
+	return false
+}
+
+// grow extends the buffer by n bytes and returns them for the caller to fill.
+func (s *Serializer) grow(n int) []byte {
+	start := len(s.buf)
+	s.buf = append(s.buf, make([]byte, n)...)
+	return s.buf[start:]
+}
+
+// deserializeComplexSlice is the counterpart of serializeComplexSlice: it
+// decodes n elements into slice, whose elements must be complex64 or
+// complex128, checking up front that the input holds them all.
+func (d *Deserializer) deserializeComplexSlice(slice reflect.Value, n uint) (bool, error) {
+	var size uint
+	switch slice.Type().Elem() {
+	case complex64Type:
+		size = 8
+	case complex128Type:
+		size = 16
+	default:
+		return false, nil
+	}
+	if n > uint(len(d.data)-d.pos)/size {
+		return true, ErrDeserializeUnexpectedEnd
+	}
+	data, _ := d.takeBytes(int(n * size))
+	if size == 8 {
+		xs := make([]complex64, n)
+		for i := range xs {
+			re := math.Float32frombits(binary.LittleEndian.Uint32(data[8*i:]))
+			im := math.Float32frombits(binary.LittleEndian.Uint32(data[8*i+4:]))
+			xs[i] = complex(re, im)
+		}
+		slice.Set(reflect.ValueOf(xs).Convert(slice.Type()))
+		return true, nil
+	}
+	xs := make([]complex128, n)
+	for i := range xs {
+		re := math.Float64frombits(binary.LittleEndian.Uint64(data[16*i:]))
+		im := math.Float64frombits(binary.LittleEndian.Uint64(data[16*i+8:]))
+		xs[i] = complex(re, im)
+	}
+	slice.Set(reflect.ValueOf(xs).Convert(slice.Type()))
+	return true, nil
+}
@@ -2,7 +2,9 @@ package postcard
 
 import (
 	"fmt"
+	"net/netip"
 	"reflect"
+	"time"
 	"unicode/utf8"
 )
 
@@ -15,6 +17,49 @@ func NewDeserializer(data []byte) *Deserializer {
 	return &Deserializer{data: data, pos: 0}
 }
 
+// Remaining returns the input not consumed yet, such as the payload that
+// follows a header.
+func (d *Deserializer) Remaining() []byte {
+	return d.data[d.pos:]
+}
+
+// PreallocLen returns how many of the n elements a length prefix claims are
+// worth allocating up front. The length comes from the input, so it is only
+// trusted as far as the remaining input could hold one byte per element;
+// longer slices grow while decoding, which fails at the end of the input if
+// the length lied. Code generated by postcard-gen uses it too.
+func (d *Deserializer) PreallocLen(n uint) int {
+	if remaining := uint(len(d.data) - d.pos); n > remaining {
+		return int(remaining)
+	}
+	return int(n)
+}
+
+// MaxZeroSizeLen bounds the length of a sequence or map whose elements may
+// encode in no bytes, such as a []Unit, where the length exceeds the
+// remaining input. Decoding such elements consumes nothing, so without a
+// bound a hostile length would keep the decoder looping.
+const MaxZeroSizeLen = 1 << 16
+
+// CheckLen checks a length prefix of n elements that each encode in at least
+// minSize bytes against the remaining input. It returns
+// ErrDeserializeUnexpectedEnd if the input cannot hold them, or
+// ErrCapacityExceeded if minSize is 0 and n exceeds both the remaining input
+// and MaxZeroSizeLen. Code generated by postcard-gen uses it too.
+func (d *Deserializer) CheckLen(n uint, minSize int) error {
+	remaining := uint(len(d.data) - d.pos)
+	if minSize > 0 {
+		if n > remaining/uint(minSize) {
+			return ErrDeserializeUnexpectedEnd
+		}
+		return nil
+	}
+	if n > remaining && n > MaxZeroSizeLen {
+		return ErrCapacityExceeded
+	}
+	return nil
+}
+
 func (d *Deserializer) popByte() (byte, error) {
 	if d.pos >= len(d.data) {
 		return 0, ErrDeserializeUnexpectedEnd
@@ -25,7 +70,7 @@ func (d *Deserializer) popByte() (byte, error) {
 }
 
 func (d *Deserializer) takeBytes(n int) ([]byte, error) {
-	if d.pos+n > len(d.data) {
+	if n < 0 || n > len(d.data)-d.pos {
 		return nil, ErrDeserializeUnexpectedEnd
 	}
 	result := d.data[d.pos : d.pos+n]
@@ -129,16 +174,11 @@ func (d *Deserializer) DeserializeString() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if sz > 4 {
-		bytes, err := d.takeBytes(int(sz))
-		if err != nil {
-			return "", err
-		}
-		if !utf8.Valid(bytes) {
-			return "", ErrDeserializeBadUtf8
-		}
-		return string(bytes), nil
-	}
+	return d.takeString(sz)
+}
+
+// takeString reads a string of sz bytes, checking that it is valid UTF-8.
+func (d *Deserializer) takeString(sz uint) (string, error) {
 	bytes, err := d.takeBytes(int(sz))
 	if err != nil {
 		return "", err
@@ -157,6 +197,21 @@ func (d *Deserializer) DeserializeBytes() ([]byte, error) {
 	return d.takeBytes(int(sz))
 }
 
+// DeserializeByteArray reads length-prefixed bytes into dst, which they
+// must fill exactly. It is the counterpart of SerializeBytes for fixed-size
+// arrays, such as a Rust [u8; N] marked #[serde(with = "serde_bytes")].
+func (d *Deserializer) DeserializeByteArray(dst []byte) error {
+	b, err := d.DeserializeBytes()
+	if err != nil {
+		return err
+	}
+	if len(b) != len(dst) {
+		return fmt.Errorf("%w: got %d bytes, want %d", ErrDeserializeBadEncoding, len(b), len(dst))
+	}
+	copy(dst, b)
+	return nil
+}
+
 func (d *Deserializer) DeserializeOption(v interface{}) error {
 	b, err := d.popByte()
 	if err != nil {
@@ -187,24 +242,37 @@ func (d *Deserializer) DeserializeSlice(v interface{}) error {
 		return fmt.Errorf("expected pointer to slice, got %T", v)
 	}
 
-	slice := rv.Elem()
-
 	sz, err := d.DeserializeUint()
 	if err != nil {
 		return err
 	}
+	return d.deserializeSliceLen(rv.Elem(), sz)
+}
+
+// deserializeSliceLen decodes sz elements into slice, once the length has
+// been read.
+func (d *Deserializer) deserializeSliceLen(slice reflect.Value, sz uint) error {
+	if ok, err := d.deserializeComplexSlice(slice, sz); ok {
+		return err
+	}
+	if err := d.CheckLen(sz, minSizeOf(slice.Type().Elem())); err != nil {
+		return err
+	}
 
-	if slice.IsNil() || slice.Cap() < int(sz) {
-		slice.Set(reflect.MakeSlice(slice.Type(), int(sz), int(sz)))
+	n := d.PreallocLen(sz)
+	if slice.IsNil() || slice.Cap() < n {
+		slice.Set(reflect.MakeSlice(slice.Type(), 0, n))
 	} else {
-		slice.SetLen(int(sz))
+		slice.SetLen(0)
 	}
 
-	for i := 0; i < int(sz); i++ {
-		elem := slice.Index(i)
-		if err := d.DeserializeValue(elem.Addr().Interface()); err != nil {
+	elemType := slice.Type().Elem()
+	for i := uint(0); i < sz; i++ {
+		elem := reflect.New(elemType)
+		if err := d.DeserializeValue(elem.Interface()); err != nil {
 			return err
 		}
+		slice.Set(reflect.Append(slice, elem.Elem()))
 	}
 	return nil
 }
@@ -232,21 +300,27 @@ func (d *Deserializer) DeserializeMap(v interface{}) error {
 		return fmt.Errorf("expected pointer to map, got %T", v)
 	}
 
-	m := rv.Elem()
-
-	if m.IsNil() {
-		m.Set(reflect.MakeMap(m.Type()))
-	}
-
 	sz, err := d.DeserializeUint()
 	if err != nil {
 		return err
 	}
+	return d.deserializeMapLen(rv.Elem(), sz)
+}
+
+// deserializeMapLen decodes sz entries into m, once the length has been
+// read.
+func (d *Deserializer) deserializeMapLen(m reflect.Value, sz uint) error {
+	if err := d.CheckLen(sz, minSizeOf(m.Type().Key())+minSizeOf(m.Type().Elem())); err != nil {
+		return err
+	}
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
 
 	keyType := m.Type().Key()
 	elemType := m.Type().Elem()
 
-	for i := 0; i < int(sz); i++ {
+	for i := uint(0); i < sz; i++ {
 		key := reflect.New(keyType).Elem()
 		if err := d.DeserializeValue(key.Addr().Interface()); err != nil {
 			return err
@@ -279,16 +353,17 @@ func (d *Deserializer) DeserializeStruct(v interface{}) error {
 	}
 
 	typ := val.Type()
+	if err := checkExported(typ); err != nil {
+		return err
+	}
 	for i := 0; i < val.NumField(); i++ {
 		field := typ.Field(i)
 		if field.PkgPath != "" {
 			continue
 		}
 		fieldVal := val.Field(i)
-		if fieldVal.CanAddr() {
-			if err := d.DeserializeValue(fieldVal.Addr().Interface()); err != nil {
-				return err
-			}
+		if err := d.deserializeField(field, fieldVal); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -323,6 +398,37 @@ func (d *Deserializer) DeserializeValue(v interface{}) error {
 		rv.Set(reflect.New(rv.Type().Elem()))
 	}
 
+	if u, ok := v.(Unmarshaler); ok {
+		return u.UnmarshalPostcard(d)
+	}
+	switch p := v.(type) {
+	case *time.Duration:
+		return (*Duration)(p).UnmarshalPostcard(d)
+	case *time.Time:
+		return (*UnixSeconds)(p).UnmarshalPostcard(d)
+	case *netip.Addr:
+		a, err := d.DeserializeAddr()
+		if err != nil {
+			return err
+		}
+		*p = a
+		return nil
+	case *netip.AddrPort:
+		ap, err := d.DeserializeAddrPort()
+		if err != nil {
+			return err
+		}
+		*p = ap
+		return nil
+	case *netip.Prefix:
+		prefix, err := d.DeserializePrefix()
+		if err != nil {
+			return err
+		}
+		*p = prefix
+		return nil
+	}
+
 	val := rv.Elem()
 
 	switch val.Kind() {
@@ -410,6 +516,18 @@ func (d *Deserializer) DeserializeValue(v interface{}) error {
 			return err
 		}
 		val.SetFloat(decoded)
+	case reflect.Complex64:
+		decoded, err := d.DeserializeComplex64()
+		if err != nil {
+			return err
+		}
+		val.SetComplex(complex128(decoded))
+	case reflect.Complex128:
+		decoded, err := d.DeserializeComplex128()
+		if err != nil {
+			return err
+		}
+		val.SetComplex(decoded)
 	case reflect.String:
 		decoded, err := d.DeserializeString()
 		if err != nil {
@@ -432,6 +550,12 @@ func (d *Deserializer) DeserializeValue(v interface{}) error {
 		return d.DeserializeMap(v)
 	case reflect.Struct:
 		return d.DeserializeStruct(v)
+	case reflect.Interface:
+		info := lookupEnum(val.Type())
+		if info == nil {
+			return fmt.Errorf("unsupported type: %v", val.Type())
+		}
+		return d.deserializeEnumValue(info, val)
 	default:
 		return fmt.Errorf("unsupported type: %v", val.Kind())
 	}
@@ -520,20 +644,5 @@ func DeserializeBytes(data []byte) ([]byte, error) {
 
 func DeserializeRune(data []byte) (rune, error) {
 	d := NewDeserializer(data)
-	sz, err := d.DeserializeUint()
-	if err != nil {
-		return 0, err
-	}
-	if sz > 4 {
-		return 0, ErrDeserializeBadChar
-	}
-	bytes, err := d.takeBytes(int(sz))
-	if err != nil {
-		return 0, err
-	}
-	r, _ := utf8.DecodeRune(bytes)
-	if r == utf8.RuneError {
-		return 0, ErrDeserializeBadChar
-	}
-	return r, nil
+	return d.DeserializeChar()
 }
@@ -0,0 +1,108 @@
+package postcard
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type enumInfo struct {
+	variants []reflect.Type
+	index    map[reflect.Type]uint32
+}
+
+var (
+	enumsMu sync.RWMutex
+	enums   = map[reflect.Type]*enumInfo{}
+)
+
+// RegisterEnum declares that the interface type I models a Rust enum. The
+// dynamic types of variants are its variants, in discriminant order; unit
+// variants are usually empty structs.
+//
+// Struct fields, elements and pointers of type I are then encoded as postcard
+// enums: a varint discriminant followed by the variant's value.
+//
+//	type Shape interface{ isShape() }
+//
+//	func init() {
+//		postcard.RegisterEnum[Shape](Circle{}, Square{}, Empty{})
+//	}
+func RegisterEnum[I any](variants ...I) {
+	t := reflect.TypeOf((*I)(nil)).Elem()
+	if t.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("postcard: RegisterEnum: %v is not an interface type", t))
+	}
+
+	info := &enumInfo{index: make(map[reflect.Type]uint32, len(variants))}
+	for i, v := range variants {
+		vt := reflect.TypeOf(v)
+		if vt == nil {
+			panic(fmt.Sprintf("postcard: RegisterEnum: variant %d of %v is nil", i, t))
+		}
+		if _, dup := info.index[vt]; dup {
+			panic(fmt.Sprintf("postcard: RegisterEnum: %v registered twice for %v", vt, t))
+		}
+		info.variants = append(info.variants, vt)
+		info.index[vt] = uint32(i)
+	}
+
+	enumsMu.Lock()
+	enums[t] = info
+	enumsMu.Unlock()
+}
+
+func lookupEnum(t reflect.Type) *enumInfo {
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+	return enums[t]
+}
+
+// serializeReflect serializes val keeping its static type, so values held in
+// registered enum interfaces get their discriminant.
+func (s *Serializer) serializeReflect(val reflect.Value) error {
+	if val.Kind() == reflect.Interface {
+		if info := lookupEnum(val.Type()); info != nil {
+			return s.serializeEnumValue(info, val)
+		}
+	}
+	return s.SerializeValue(val.Interface())
+}
+
+func (s *Serializer) serializeEnumValue(info *enumInfo, val reflect.Value) error {
+	if val.IsNil() {
+		return fmt.Errorf("nil value for enum %v", val.Type())
+	}
+	elem := val.Elem()
+	idx, ok := info.index[elem.Type()]
+	if !ok {
+		return fmt.Errorf("%v is not a registered variant of %v", elem.Type(), val.Type())
+	}
+	return s.SerializeEnum(idx, elem.Interface())
+}
+
+func (d *Deserializer) deserializeEnumValue(info *enumInfo, val reflect.Value) error {
+	idx, err := d.DeserializeUint32()
+	if err != nil {
+		return err
+	}
+	if int(idx) >= len(info.variants) {
+		return fmt.Errorf("%w: unknown variant %d of %v", ErrDeserializeBadEnum, idx, val.Type())
+	}
+
+	vt := info.variants[idx]
+	if vt.Kind() == reflect.Ptr {
+		p := reflect.New(vt.Elem())
+		if err := d.DeserializeValue(p.Interface()); err != nil {
+			return err
+		}
+		val.Set(p)
+		return nil
+	}
+	p := reflect.New(vt)
+	if err := d.DeserializeValue(p.Interface()); err != nil {
+		return err
+	}
+	val.Set(p.Elem())
+	return nil
+}
@@ -16,6 +16,7 @@ var (
 	ErrDeserializeBadEnum        = errors.New("found an enum discriminant that was > u32::max_value()")
 	ErrDeserializeBadEncoding    = errors.New("the original data was not well encoded")
 	ErrDeserializeBadCrc         = errors.New("bad CRC while deserializing")
+	ErrCapacityExceeded          = errors.New("a sequence, string or map is longer than its max")
 	ErrSerdeSerCustom            = errors.New("serde serialization error")
 	ErrSerdeDeCustom             = errors.New("serde deserialization error")
 	ErrCollectStrError           = errors.New("error while processing collect_str during serialization")
@@ -0,0 +1,190 @@
+package postcard
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Fixed-width integers, the counterpart of postcard::fixint. They encode in
+// their full width with the byte order in their name instead of as varints,
+// which suits register values and other fields that rarely have small
+// values. A struct field of a plain integer type can get the same encoding
+// with the tag `postcard:"fixint=le"` or `postcard:"fixint=be"`.
+type (
+	FixU16LE uint16
+	FixU32LE uint32
+	FixU64LE uint64
+	FixI16LE int16
+	FixI32LE int32
+	FixI64LE int64
+
+	FixU16BE uint16
+	FixU32BE uint32
+	FixU64BE uint64
+	FixI16BE int16
+	FixI32BE int32
+	FixI64BE int64
+)
+
+func (s *Serializer) pushFixint(n uint64, size int, bigEndian bool) error {
+	switch {
+	case size == 2 && bigEndian:
+		return s.pushBytes(encodeUint16BE(uint16(n)))
+	case size == 2:
+		return s.pushBytes(encodeUint16LE(uint16(n)))
+	case size == 4 && bigEndian:
+		return s.pushBytes(encodeUint32BE(uint32(n)))
+	case size == 4:
+		return s.pushBytes(encodeUint32LE(uint32(n)))
+	case size == 8 && bigEndian:
+		return s.pushBytes(encodeUint64BE(n))
+	default:
+		return s.pushBytes(encodeUint64LE(n))
+	}
+}
+
+func (d *Deserializer) takeFixint(size int, bigEndian bool) (uint64, error) {
+	switch {
+	case size == 2 && bigEndian:
+		v, err := decodeUint16BE(d.data, &d.pos)
+		return uint64(v), err
+	case size == 2:
+		v, err := decodeUint16LE(d.data, &d.pos)
+		return uint64(v), err
+	case size == 4 && bigEndian:
+		v, err := decodeUint32BE(d.data, &d.pos)
+		return uint64(v), err
+	case size == 4:
+		v, err := decodeUint32LE(d.data, &d.pos)
+		return uint64(v), err
+	case size == 8 && bigEndian:
+		return decodeUint64BE(d.data, &d.pos)
+	default:
+		return decodeUint64LE(d.data, &d.pos)
+	}
+}
+
+// fixintSize returns the width of the integer type t, which must be 16, 32
+// or 64 bits; int and uint are refused since their width varies.
+func fixintSize(t reflect.Type) (int, error) {
+	switch t.Kind() {
+	case reflect.Int16, reflect.Uint16:
+		return 2, nil
+	case reflect.Int32, reflect.Uint32:
+		return 4, nil
+	case reflect.Int64, reflect.Uint64:
+		return 8, nil
+	}
+	return 0, fmt.Errorf("fixint needs a 16, 32 or 64-bit integer, got %v", t)
+}
+
+// serializeFixint encodes the integer val in full width, for fields tagged
+// `postcard:"fixint=..."`.
+func (s *Serializer) serializeFixint(val reflect.Value, bigEndian bool) error {
+	size, err := fixintSize(val.Type())
+	if err != nil {
+		return err
+	}
+	var n uint64
+	switch val.Kind() {
+	case reflect.Int16, reflect.Int32, reflect.Int64:
+		n = uint64(val.Int())
+	default:
+		n = val.Uint()
+	}
+	return s.pushFixint(n, size, bigEndian)
+}
+
+func (d *Deserializer) deserializeFixint(val reflect.Value, bigEndian bool) error {
+	size, err := fixintSize(val.Type())
+	if err != nil {
+		return err
+	}
+	n, err := d.takeFixint(size, bigEndian)
+	if err != nil {
+		return err
+	}
+	switch val.Kind() {
+	case reflect.Int16, reflect.Int32, reflect.Int64:
+		// Sign-extend from the encoded width.
+		shift := 64 - 8*size
+		val.SetInt(int64(n<<shift) >> shift)
+	default:
+		val.SetUint(n)
+	}
+	return nil
+}
+
+func (v FixU16LE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 2, false) }
+func (v FixU32LE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 4, false) }
+func (v FixU64LE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 8, false) }
+func (v FixI16LE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 2, false) }
+func (v FixI32LE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 4, false) }
+func (v FixI64LE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 8, false) }
+func (v FixU16BE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 2, true) }
+func (v FixU32BE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 4, true) }
+func (v FixU64BE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 8, true) }
+func (v FixI16BE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 2, true) }
+func (v FixI32BE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 4, true) }
+func (v FixI64BE) MarshalPostcard(s *Serializer) error { return s.pushFixint(uint64(v), 8, true) }
+
+func (v *FixU16LE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), false)
+}
+
+func (v *FixU32LE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), false)
+}
+
+func (v *FixU64LE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), false)
+}
+
+func (v *FixI16LE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), false)
+}
+
+func (v *FixI32LE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), false)
+}
+
+func (v *FixI64LE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), false)
+}
+
+func (v *FixU16BE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), true)
+}
+
+func (v *FixU32BE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), true)
+}
+
+func (v *FixU64BE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), true)
+}
+
+func (v *FixI16BE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), true)
+}
+
+func (v *FixI32BE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), true)
+}
+
+func (v *FixI64BE) UnmarshalPostcard(d *Deserializer) error {
+	return d.deserializeFixint(reflect.ValueOf(v).Elem(), true)
+}
+
+func (FixU16LE) SizePostcard() int { return 2 }
+func (FixU32LE) SizePostcard() int { return 4 }
+func (FixU64LE) SizePostcard() int { return 8 }
+func (FixI16LE) SizePostcard() int { return 2 }
+func (FixI32LE) SizePostcard() int { return 4 }
+func (FixI64LE) SizePostcard() int { return 8 }
+func (FixU16BE) SizePostcard() int { return 2 }
+func (FixU32BE) SizePostcard() int { return 4 }
+func (FixU64BE) SizePostcard() int { return 8 }
+func (FixI16BE) SizePostcard() int { return 2 }
+func (FixI32BE) SizePostcard() int { return 4 }
+func (FixI64BE) SizePostcard() int { return 8 }
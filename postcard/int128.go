@@ -0,0 +1,152 @@
+package postcard
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Uint128 is a Rust u128, encoded as a varint of up to 19 bytes.
+type Uint128 struct {
+	Hi, Lo uint64
+}
+
+// Int128 is a Rust i128 in two's complement, encoded as a zigzag varint.
+type Int128 struct {
+	Hi, Lo uint64
+}
+
+// Uint128From64 returns v as a Uint128.
+func Uint128From64(v uint64) Uint128 {
+	return Uint128{Lo: v}
+}
+
+// Int128From64 returns v as an Int128.
+func Int128From64(v int64) Int128 {
+	return Int128{Hi: uint64(v >> 63), Lo: uint64(v)}
+}
+
+var (
+	bigTwo128 = new(big.Int).Lsh(big.NewInt(1), 128)
+	bigMask64 = new(big.Int).SetUint64(^uint64(0))
+)
+
+func bigFromHiLo(hi, lo uint64) *big.Int {
+	b := new(big.Int).SetUint64(hi)
+	b.Lsh(b, 64)
+	return b.Or(b, new(big.Int).SetUint64(lo))
+}
+
+func hiLoFromBig(b *big.Int) (hi, lo uint64) {
+	lo = new(big.Int).And(b, bigMask64).Uint64()
+	hi = new(big.Int).Rsh(b, 64).Uint64()
+	return hi, lo
+}
+
+// Uint128FromBig converts b, which must be in [0, 2^128).
+func Uint128FromBig(b *big.Int) (Uint128, error) {
+	if b.Sign() < 0 || b.BitLen() > 128 {
+		return Uint128{}, fmt.Errorf("%v overflows u128", b)
+	}
+	hi, lo := hiLoFromBig(b)
+	return Uint128{Hi: hi, Lo: lo}, nil
+}
+
+// Int128FromBig converts b, which must be in [-2^127, 2^127).
+func Int128FromBig(b *big.Int) (Int128, error) {
+	u := b
+	if b.Sign() < 0 {
+		// Two's complement: negative values map to [2^127, 2^128).
+		u = new(big.Int).Add(b, bigTwo128)
+		if u.Sign() < 0 || u.BitLen() != 128 {
+			return Int128{}, fmt.Errorf("%v overflows i128", b)
+		}
+	} else if b.BitLen() > 127 {
+		return Int128{}, fmt.Errorf("%v overflows i128", b)
+	}
+	hi, lo := hiLoFromBig(u)
+	return Int128{Hi: hi, Lo: lo}, nil
+}
+
+// Big returns v as a big.Int.
+func (v Uint128) Big() *big.Int {
+	return bigFromHiLo(v.Hi, v.Lo)
+}
+
+// Big returns v as a big.Int.
+func (v Int128) Big() *big.Int {
+	b := bigFromHiLo(v.Hi, v.Lo)
+	if v.Hi>>63 != 0 {
+		b.Sub(b, bigTwo128)
+	}
+	return b
+}
+
+func (v Uint128) String() string { return v.Big().String() }
+func (v Int128) String() string  { return v.Big().String() }
+
+func (s *Serializer) SerializeUint128(v Uint128) error {
+	return s.pushBytes(encodeVarintUint128(v.Hi, v.Lo))
+}
+
+func (s *Serializer) SerializeInt128(v Int128) error {
+	return s.pushBytes(encodeVarintUint128(zigzagEncodeInt128(v.Hi, v.Lo)))
+}
+
+func (d *Deserializer) DeserializeUint128() (Uint128, error) {
+	hi, lo, err := decodeVarintUint128(d.data, &d.pos)
+	if err != nil {
+		return Uint128{}, err
+	}
+	return Uint128{Hi: hi, Lo: lo}, nil
+}
+
+func (d *Deserializer) DeserializeInt128() (Int128, error) {
+	hi, lo, err := decodeVarintUint128(d.data, &d.pos)
+	if err != nil {
+		return Int128{}, err
+	}
+	hi, lo = zigzagDecodeInt128(hi, lo)
+	return Int128{Hi: hi, Lo: lo}, nil
+}
+
+func (v Uint128) MarshalPostcard(s *Serializer) error {
+	return s.SerializeUint128(v)
+}
+
+func (v *Uint128) UnmarshalPostcard(d *Deserializer) error {
+	decoded, err := d.DeserializeUint128()
+	if err != nil {
+		return err
+	}
+	*v = decoded
+	return nil
+}
+
+func (v Uint128) SizePostcard() int {
+	return len(encodeVarintUint128(v.Hi, v.Lo))
+}
+
+func (Uint128) PostcardSchema() (*Schema, error) {
+	return &Schema{Name: "u128", Kind: SchemaU128}, nil
+}
+
+func (v Int128) MarshalPostcard(s *Serializer) error {
+	return s.SerializeInt128(v)
+}
+
+func (v *Int128) UnmarshalPostcard(d *Deserializer) error {
+	decoded, err := d.DeserializeInt128()
+	if err != nil {
+		return err
+	}
+	*v = decoded
+	return nil
+}
+
+func (v Int128) SizePostcard() int {
+	return len(encodeVarintUint128(zigzagEncodeInt128(v.Hi, v.Lo)))
+}
+
+func (Int128) PostcardSchema() (*Schema, error) {
+	return &Schema{Name: "i128", Kind: SchemaI128}, nil
+}
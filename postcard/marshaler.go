@@ -0,0 +1,58 @@
+package postcard
+
+// Marshaler is implemented by types that encode themselves. SerializeValue
+// calls MarshalPostcard instead of walking the value with reflection.
+type Marshaler interface {
+	MarshalPostcard(s *Serializer) error
+}
+
+// Unmarshaler is implemented by pointer types that decode themselves.
+// DeserializeValue calls UnmarshalPostcard instead of using reflection.
+type Unmarshaler interface {
+	UnmarshalPostcard(d *Deserializer) error
+}
+
+// Sizer is implemented by types that know their encoded size without
+// serializing.
+type Sizer interface {
+	SizePostcard() int
+}
+
+// MaxSizer is implemented by types that encode themselves and know the most
+// bytes they can take. MaxSizeOf uses it instead of reflection.
+type MaxSizer interface {
+	MaxSizePostcard() int
+}
+
+// SizeOf returns the number of bytes Serialize would produce for v. Values
+// that cannot be serialized report 0; serializing them returns the error.
+func SizeOf(v interface{}) int {
+	if sz, ok := v.(Sizer); ok {
+		return sz.SizePostcard()
+	}
+	s := NewSerializer(nil)
+	if err := s.SerializeValue(v); err != nil {
+		return 0
+	}
+	return len(s.buf)
+}
+
+// SizeOfUint returns the encoded size of an unsigned varint.
+func SizeOfUint(v uint64) int {
+	return Varint(v).Size()
+}
+
+// SizeOfInt returns the encoded size of a zigzag signed varint.
+func SizeOfInt(v int64) int {
+	return Varint(zigzagEncodeInt64(v)).Size()
+}
+
+// SizeOfString returns the encoded size of a length-prefixed string.
+func SizeOfString(v string) int {
+	return SizeOfUint(uint64(len(v))) + len(v)
+}
+
+// SizeOfBytes returns the encoded size of a length-prefixed byte slice.
+func SizeOfBytes(v []byte) int {
+	return SizeOfUint(uint64(len(v))) + len(v)
+}
@@ -0,0 +1,232 @@
+package postcard
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var maxSizerType = reflect.TypeOf((*MaxSizer)(nil)).Elem()
+
+// maxSizes holds the largest encodings of the types that encode themselves
+// differently from their reflection.
+var maxSizes = map[reflect.Type]int{
+	durationType:          15,
+	timeType:              10,
+	addrType:              17,
+	addrPortType:          20,
+	prefixType:            18,
+	typeOf[Duration]():    15,
+	typeOf[UnixSeconds](): 10,
+	typeOf[UnixMillis]():  10,
+	typeOf[UnixMicros]():  10,
+	typeOf[UnixNanos]():   10,
+	typeOf[Uint128]():     19,
+	typeOf[Int128]():      19,
+	typeOf[UUID]():        17,
+	typeOf[FixU16LE]():    2,
+	typeOf[FixU32LE]():    4,
+	typeOf[FixU64LE]():    8,
+	typeOf[FixI16LE]():    2,
+	typeOf[FixI32LE]():    4,
+	typeOf[FixI64LE]():    8,
+	typeOf[FixU16BE]():    2,
+	typeOf[FixU32BE]():    4,
+	typeOf[FixU64BE]():    8,
+	typeOf[FixI16BE]():    2,
+	typeOf[FixI32BE]():    4,
+	typeOf[FixI64BE]():    8,
+}
+
+// MaxSizeFor returns the largest encoding of a T. See MaxSizeOf.
+func MaxSizeFor[T any]() (int, error) {
+	return MaxSizeOf(typeOf[T]())
+}
+
+// MaxSizeOf returns the most bytes SerializeValue can produce for a value of
+// type t, like POSTCARD_MAX_SIZE in Rust: a buffer that size always fits one.
+// Varints count at their longest. Slices, strings and maps need a bound, from
+// a Vec or String type or a `postcard:"max=N"` tag; types that contain one
+// without a bound, or contain themselves, have no max size. Types with a
+// MarshalPostcard method are taken to encode like their reflection, as
+// postcard-gen's do, unless they implement MaxSizer.
+func MaxSizeOf(t reflect.Type) (int, error) {
+	return maxSizeOf(t, map[reflect.Type]bool{})
+}
+
+func maxSizeOf(t reflect.Type, visiting map[reflect.Type]bool) (int, error) {
+	if t.Implements(maxSizerType) {
+		return reflect.Zero(t).Interface().(MaxSizer).MaxSizePostcard(), nil
+	}
+	if reflect.PtrTo(t).Implements(maxSizerType) {
+		return reflect.New(t).Interface().(MaxSizer).MaxSizePostcard(), nil
+	}
+	if n, ok := maxSizes[t]; ok {
+		return n, nil
+	}
+	if visiting[t] {
+		return 0, fmt.Errorf("recursive type %v has no max size", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	if t.Kind() != reflect.Ptr && t.Implements(boundedType) {
+		return maxSizeBounded(t, reflect.Zero(t).Interface().(interface{ maxLen() int }).maxLen(), visiting)
+	}
+	if t.Kind() == reflect.Struct && t.Implements(optionType) {
+		n, err := maxSizeOf(reflect.Zero(t).Interface().(interface{ optionElem() reflect.Type }).optionElem(), visiting)
+		return 1 + n, err
+	}
+	if t.Kind() == reflect.Struct && t.Implements(resultType) {
+		okType, errType := reflect.Zero(t).Interface().(interface{ resultElems() (ok, err reflect.Type) }).resultElems()
+		okSize, err := maxSizeOf(okType, visiting)
+		if err != nil {
+			return 0, err
+		}
+		errSize, err := maxSizeOf(errType, visiting)
+		if err != nil {
+			return 0, err
+		}
+		return 1 + max(okSize, errSize), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1, nil
+	case reflect.Int16, reflect.Uint16:
+		return 3, nil
+	case reflect.Int32, reflect.Uint32:
+		return 5, nil
+	case reflect.Int64, reflect.Uint64, reflect.Int, reflect.Uint:
+		return 10, nil
+	case reflect.Float32:
+		return 4, nil
+	case reflect.Float64, reflect.Complex64:
+		return 8, nil
+	case reflect.Complex128:
+		return 16, nil
+	case reflect.String, reflect.Slice, reflect.Map:
+		return 0, fmt.Errorf("%v is unbounded; it needs a max tag or a Vec or String type", t)
+	case reflect.Array:
+		n, err := maxSizeOf(t.Elem(), visiting)
+		return n * t.Len(), err
+	case reflect.Ptr:
+		n, err := maxSizeOf(t.Elem(), visiting)
+		return max(n, 1), err
+	case reflect.Struct:
+		if err := checkExported(t); err != nil {
+			return 0, err
+		}
+		total := 0
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			n, err := maxSizeOfField(f, visiting)
+			if err != nil {
+				return 0, fmt.Errorf("%v.%s: %w", t, f.Name, err)
+			}
+			total += n
+		}
+		return total, nil
+	case reflect.Interface:
+		info := lookupEnum(t)
+		if info == nil {
+			return 0, fmt.Errorf("interface %v is not a registered enum", t)
+		}
+		largest := 0
+		for _, vt := range info.variants {
+			if vt.Kind() == reflect.Ptr {
+				vt = vt.Elem()
+			}
+			n, err := maxSizeOf(vt, visiting)
+			if err != nil {
+				return 0, err
+			}
+			largest = max(largest, n)
+		}
+		return SizeOfUint(uint64(max(len(info.variants)-1, 0))) + largest, nil
+	}
+	return 0, fmt.Errorf("type %v has no max size", t)
+}
+
+// maxSizeOfField is maxSizeOf for a struct field, whose tag may change the
+// encoding of its type.
+func maxSizeOfField(f reflect.StructField, visiting map[reflect.Type]bool) (int, error) {
+	tag, err := parseFieldTag(f)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case tag.Fixint != "":
+		return fixintSize(f.Type)
+	case tag.Char:
+		return 5, nil
+	case tag.Unix != "":
+		return maxSizes[timeType], nil
+	case tag.Bytes && isByteArray(f.Type):
+		return SizeOfUint(uint64(f.Type.Len())) + f.Type.Len(), nil
+	case tag.Max > 0:
+		return maxSizeBounded(f.Type, tag.Max, visiting)
+	}
+	return maxSizeOf(f.Type, visiting)
+}
+
+// maxSizeBounded returns the largest encoding of t, a slice, string or map
+// of at most n elements or an Option of one.
+func maxSizeBounded(t reflect.Type, n int, visiting map[reflect.Type]bool) (int, error) {
+	if t.Kind() == reflect.Ptr {
+		size, err := maxSizeBounded(t.Elem(), n, visiting)
+		return max(size, 1), err
+	}
+	if t.Kind() == reflect.Struct && t.Implements(optionType) {
+		size, err := maxSizeBounded(reflect.Zero(t).Interface().(interface{ optionElem() reflect.Type }).optionElem(), n, visiting)
+		return 1 + size, err
+	}
+	head := SizeOfUint(uint64(n))
+	switch t.Kind() {
+	case reflect.String:
+		return head + n, nil
+	case reflect.Slice:
+		elem, err := maxSizeOf(t.Elem(), visiting)
+		return head + n*elem, err
+	case reflect.Map:
+		key, err := maxSizeOf(t.Key(), visiting)
+		if err != nil {
+			return 0, err
+		}
+		val, err := maxSizeOf(t.Elem(), visiting)
+		return head + n*(key+val), err
+	}
+	return 0, fmt.Errorf("max needs a slice, string or map, got %v", t)
+}
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// minSizeOf returns a lower bound on the bytes DeserializeValue consumes for
+// a value of type t, which CheckLen holds a length prefix against. It is 0
+// for types that may encode in nothing, such as Unit and struct{}, and for
+// those it cannot tell, such as types with an UnmarshalPostcard method.
+func minSizeOf(t reflect.Type) int {
+	if t.Kind() == reflect.Struct && (t.Implements(optionType) || t.Implements(resultType)) {
+		return 1
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return 0
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return 0
+	case reflect.Array:
+		return t.Len() * minSizeOf(t.Elem())
+	case reflect.Struct:
+		n := 0
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() {
+				n += minSizeOf(f.Type)
+			}
+		}
+		return n
+	}
+	return 1
+}
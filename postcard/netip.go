@@ -0,0 +1,145 @@
+package postcard
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+)
+
+// IP addresses encode like Rust's std::net types do for non-human-readable
+// formats. An Addr is an IpAddr: variant 0 (V4) followed by the 4 address
+// bytes, or variant 1 (V6) followed by 16. An AddrPort is a SocketAddr, the
+// same enum with a u16 port after the address, and a Prefix is an
+// ipnet::IpNet, with the prefix length as a u8 after the address. IPv6
+// zones and Rust's flow info and scope ID are not part of the encoding, so
+// addresses with a zone fail to encode.
+
+var (
+	addrType     = reflect.TypeOf(netip.Addr{})
+	addrPortType = reflect.TypeOf(netip.AddrPort{})
+	prefixType   = reflect.TypeOf(netip.Prefix{})
+)
+
+func (s *Serializer) SerializeAddr(a netip.Addr) error {
+	switch {
+	case !a.IsValid():
+		return fmt.Errorf("invalid IP address")
+	case a.Zone() != "":
+		return fmt.Errorf("IP address %v has a zone, which cannot be encoded", a)
+	case a.Is4():
+		b := a.As4()
+		if err := s.pushVarintUint32(0); err != nil {
+			return err
+		}
+		return s.pushBytes(b[:])
+	default:
+		b := a.As16()
+		if err := s.pushVarintUint32(1); err != nil {
+			return err
+		}
+		return s.pushBytes(b[:])
+	}
+}
+
+func (d *Deserializer) DeserializeAddr() (netip.Addr, error) {
+	variant, err := d.DeserializeUint32()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	switch variant {
+	case 0:
+		b, err := d.takeBytes(4)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		return netip.AddrFrom4([4]byte(b)), nil
+	case 1:
+		b, err := d.takeBytes(16)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		return netip.AddrFrom16([16]byte(b)), nil
+	default:
+		return netip.Addr{}, fmt.Errorf("%w: unknown IP address variant %d", ErrDeserializeBadEnum, variant)
+	}
+}
+
+func (s *Serializer) SerializeAddrPort(ap netip.AddrPort) error {
+	if err := s.SerializeAddr(ap.Addr()); err != nil {
+		return err
+	}
+	return s.SerializeUint16(ap.Port())
+}
+
+func (d *Deserializer) DeserializeAddrPort() (netip.AddrPort, error) {
+	a, err := d.DeserializeAddr()
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	port, err := d.DeserializeUint16()
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	return netip.AddrPortFrom(a, port), nil
+}
+
+func (s *Serializer) SerializePrefix(p netip.Prefix) error {
+	if !p.IsValid() {
+		return fmt.Errorf("invalid IP prefix")
+	}
+	if err := s.SerializeAddr(p.Addr()); err != nil {
+		return err
+	}
+	return s.SerializeUint8(uint8(p.Bits()))
+}
+
+// DeserializePrefix reads a Prefix, rejecting prefix lengths longer than
+// the address.
+func (d *Deserializer) DeserializePrefix() (netip.Prefix, error) {
+	a, err := d.DeserializeAddr()
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	bits, err := d.DeserializeUint8()
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if int(bits) > a.BitLen() {
+		return netip.Prefix{}, fmt.Errorf("prefix length %d is too long for %v", bits, a)
+	}
+	return netip.PrefixFrom(a, int(bits)), nil
+}
+
+// netipSchema describes addrType, addrPortType or prefixType as the Rust
+// type it encodes like.
+func netipSchema(t reflect.Type) *Schema {
+	octets := func(n int, more ...*Schema) []*Schema {
+		elems := make([]*Schema, n, n+len(more))
+		for i := range elems {
+			elems[i] = &Schema{Name: "u8", Kind: SchemaU8}
+		}
+		return append(elems, more...)
+	}
+	enum := func(name string, v4, v6 *Schema) *Schema {
+		return &Schema{Name: name, Kind: SchemaEnum, Variants: []SchemaVariant{
+			{Name: "V4", Kind: VariantNewtype, Elems: []*Schema{v4}},
+			{Name: "V6", Kind: VariantNewtype, Elems: []*Schema{v6}},
+		}}
+	}
+	v4 := &Schema{Name: "Ipv4Addr", Kind: SchemaTuple, Elems: octets(4)}
+	v6 := &Schema{Name: "Ipv6Addr", Kind: SchemaTuple, Elems: octets(16)}
+	switch t {
+	case addrType:
+		return enum("IpAddr", v4, v6)
+	case addrPortType:
+		port := &Schema{Name: "u16", Kind: SchemaU16}
+		return enum("SocketAddr",
+			&Schema{Name: "SocketAddrV4", Kind: SchemaTuple, Elems: []*Schema{v4, port}},
+			&Schema{Name: "SocketAddrV6", Kind: SchemaTuple, Elems: []*Schema{v6, port}})
+	default:
+		bits := &Schema{Name: "u8", Kind: SchemaU8}
+		return enum("IpNet",
+			&Schema{Name: "Ipv4Net", Kind: SchemaTuple, Elems: octets(4, bits)},
+			&Schema{Name: "Ipv6Net", Kind: SchemaTuple, Elems: octets(16, bits)})
+	}
+}
@@ -0,0 +1,54 @@
+package postcard
+
+import "reflect"
+
+// Option models a Rust Option<T>: a 0 byte for None, or a 1 byte followed by
+// the value for Some. Plain Go pointers do not round-trip this way.
+type Option[T any] struct {
+	Value T
+	Valid bool
+}
+
+func Some[T any](v T) Option[T] {
+	return Option[T]{Value: v, Valid: true}
+}
+
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// Get returns the value and whether it is present.
+func (o Option[T]) Get() (T, bool) {
+	return o.Value, o.Valid
+}
+
+func (o Option[T]) MarshalPostcard(s *Serializer) error {
+	if !o.Valid {
+		return s.pushByte(0)
+	}
+	if err := s.pushByte(1); err != nil {
+		return err
+	}
+	return s.serializeReflect(reflect.ValueOf(&o.Value).Elem())
+}
+
+func (o *Option[T]) UnmarshalPostcard(d *Deserializer) error {
+	b, err := d.popByte()
+	if err != nil {
+		return err
+	}
+	switch b {
+	case 0:
+		*o = Option[T]{}
+		return nil
+	case 1:
+		o.Valid = true
+		return d.DeserializeValue(&o.Value)
+	default:
+		return ErrDeserializeBadOption
+	}
+}
+
+func (Option[T]) optionElem() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -1,9 +1,14 @@
 package postcard
 
 import (
+	"errors"
 	"math"
+	"math/big"
+	"net/netip"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestVarintUint16(t *testing.T) {
@@ -406,6 +411,99 @@ func TestSerializeDeserializeSlice(t *testing.T) {
 	}
 }
 
+func TestDeserializeHostileLength(t *testing.T) {
+	// Lengths far beyond the input must fail, not allocate or panic.
+	huge := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01, 0x01}
+	var ints []int
+	if err := Deserialize(huge, &ints); err != ErrDeserializeUnexpectedEnd {
+		t.Errorf("Deserialize slice error = %v, want %v", err, ErrDeserializeUnexpectedEnd)
+	}
+	var s string
+	if err := Deserialize(huge, &s); err != ErrDeserializeUnexpectedEnd {
+		t.Errorf("Deserialize string error = %v, want %v", err, ErrDeserializeUnexpectedEnd)
+	}
+	var m map[uint8]uint8
+	if err := Deserialize(huge, &m); err != ErrDeserializeUnexpectedEnd {
+		t.Errorf("Deserialize map error = %v, want %v", err, ErrDeserializeUnexpectedEnd)
+	}
+}
+
+func TestDeserializeHostileZeroSizeLength(t *testing.T) {
+	// Zero-size elements consume no input, so a huge length must be
+	// rejected rather than looped over.
+	huge := []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}
+	var units []Unit
+	if err := Deserialize(huge, &units); err != ErrCapacityExceeded {
+		t.Errorf("Deserialize []Unit error = %v, want %v", err, ErrCapacityExceeded)
+	}
+	var empties []struct{}
+	if err := Deserialize(huge, &empties); err != ErrCapacityExceeded {
+		t.Errorf("Deserialize []struct{} error = %v, want %v", err, ErrCapacityExceeded)
+	}
+	var m map[Unit]Unit
+	if err := Deserialize(huge, &m); err != ErrCapacityExceeded {
+		t.Errorf("Deserialize map[Unit]Unit error = %v, want %v", err, ErrCapacityExceeded)
+	}
+
+	// Up to MaxZeroSizeLen of them decode from the length alone.
+	encoded, err := Serialize(make([]Unit, MaxZeroSizeLen))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Deserialize(encoded, &units); err != nil || len(units) != MaxZeroSizeLen {
+		t.Errorf("Deserialize %d units = %d, %v", MaxZeroSizeLen, len(units), err)
+	}
+
+	// Elements of two bytes or more cannot be more than half the input.
+	type pair struct{ A, B uint8 }
+	var pairs []pair
+	if err := Deserialize([]byte{3, 1, 2, 3, 4, 5}, &pairs); err != ErrDeserializeUnexpectedEnd {
+		t.Errorf("Deserialize short []pair error = %v, want %v", err, ErrDeserializeUnexpectedEnd)
+	}
+}
+
+func TestDeserializeLengthOverflow(t *testing.T) {
+	// Once a byte is consumed, a length near the int range overflows the
+	// end position; a length past it converts to a negative int.
+	type pair struct {
+		A uint8
+		B []byte
+	}
+	inputs := [][]byte{
+		{7, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F, 1},
+		{7, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01, 1},
+	}
+	for _, in := range inputs {
+		var p pair
+		if err := Deserialize(in, &p); err != ErrDeserializeUnexpectedEnd {
+			t.Errorf("Deserialize(% x) error = %v, want %v", in, err, ErrDeserializeUnexpectedEnd)
+		}
+	}
+}
+
+func TestDeserializeSliceReuse(t *testing.T) {
+	encoded, err := Serialize([]uint16{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Decoding replaces the contents whatever the length and capacity of
+	// the slice decoded into.
+	for _, into := range [][]uint16{nil, {}, {9}, {9, 9, 9, 9}, make([]uint16, 0, 8)} {
+		if err := Deserialize(encoded, &into); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(into, []uint16{1, 2}) {
+			t.Errorf("decoded %v, want [1 2]", into)
+		}
+	}
+
+	// A length the input cannot back fails after the elements it holds.
+	var short []uint16
+	if err := Deserialize([]byte{3, 1, 2}, &short); err != ErrDeserializeUnexpectedEnd {
+		t.Errorf("Deserialize(short) error = %v, want %v", err, ErrDeserializeUnexpectedEnd)
+	}
+}
+
 func TestSerializeDeserializeStruct(t *testing.T) {
 	type BasicStruct struct {
 		A uint16
@@ -526,3 +624,907 @@ func TestVarintBoundaryCanon(t *testing.T) {
 		t.Errorf("decodeVarintUint32(%v) error = %v, want %v", badEncoded, err, ErrDeserializeBadVarint)
 	}
 }
+
+func TestVarintUint128(t *testing.T) {
+	max := bytes19(0xFF)
+	max[18] = 0x03
+	tests := []struct {
+		name     string
+		input    Uint128
+		expected []byte
+	}{
+		{"zero", Uint128{}, []byte{0x00}},
+		{"127", Uint128From64(127), []byte{0x7F}},
+		{"2^64", Uint128{Hi: 1}, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x02}},
+		{"max", Uint128{Hi: math.MaxUint64, Lo: math.MaxUint64}, max},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := Serialize(tt.input)
+			if err != nil {
+				t.Fatalf("Serialize(%v) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(encoded, tt.expected) {
+				t.Errorf("Serialize(%v) = %v, want %v", tt.input, encoded, tt.expected)
+			}
+			if n := SizeOf(tt.input); n != len(tt.expected) {
+				t.Errorf("SizeOf(%v) = %d, want %d", tt.input, n, len(tt.expected))
+			}
+			var decoded Uint128
+			if err := Deserialize(encoded, &decoded); err != nil {
+				t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+			}
+			if decoded != tt.input {
+				t.Errorf("got %v, want %v", decoded, tt.input)
+			}
+		})
+	}
+
+	overflow := bytes19(0xFF)
+	overflow[18] = 0x04
+	unterminated := append(bytes19(0xFF), 0x00)
+	for _, bad := range [][]byte{overflow, unterminated} {
+		var decoded Uint128
+		if err := Deserialize(bad, &decoded); err != ErrDeserializeBadVarint {
+			t.Errorf("Deserialize(%v) error = %v, want %v", bad, err, ErrDeserializeBadVarint)
+		}
+	}
+}
+
+func bytes19(b byte) []byte {
+	out := make([]byte, 19)
+	for i := range out {
+		out[i] = b
+	}
+	return out
+}
+
+func TestSerializeDeserializeInt128(t *testing.T) {
+	min, _ := new(big.Int).SetString("-170141183460469231731687303715884105728", 10)
+	max, _ := new(big.Int).SetString("170141183460469231731687303715884105727", 10)
+	// Zigzag maps min to 2^128-1 and max to 2^128-2.
+	allOnes := bytes19(0xFF)
+	allOnes[18] = 0x03
+	allButLast := append([]byte{0xFE}, allOnes[1:]...)
+	tests := []struct {
+		input    *big.Int
+		expected []byte
+	}{
+		{big.NewInt(0), []byte{0x00}},
+		{big.NewInt(-1), []byte{0x01}},
+		{big.NewInt(1), []byte{0x02}},
+		{big.NewInt(math.MinInt64), []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01}},
+		{min, allOnes},
+		{max, allButLast},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input.String(), func(t *testing.T) {
+			v, err := Int128FromBig(tt.input)
+			if err != nil {
+				t.Fatalf("Int128FromBig(%v) error = %v", tt.input, err)
+			}
+			encoded, err := Serialize(v)
+			if err != nil {
+				t.Fatalf("Serialize(%v) error = %v", v, err)
+			}
+			if !reflect.DeepEqual(encoded, tt.expected) {
+				t.Errorf("Serialize(%v) = %v, want %v", v, encoded, tt.expected)
+			}
+			var decoded Int128
+			if err := Deserialize(encoded, &decoded); err != nil {
+				t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+			}
+			if decoded.Big().Cmp(tt.input) != 0 {
+				t.Errorf("got %v, want %v", decoded, tt.input)
+			}
+		})
+	}
+
+	if v := Int128From64(-5); v.String() != "-5" {
+		t.Errorf("Int128From64(-5) = %v", v)
+	}
+	for _, b := range []*big.Int{new(big.Int).Add(max, big.NewInt(1)), new(big.Int).Sub(min, big.NewInt(1))} {
+		if _, err := Int128FromBig(b); err == nil {
+			t.Errorf("Int128FromBig(%v) succeeded", b)
+		}
+	}
+	if _, err := Uint128FromBig(big.NewInt(-1)); err == nil {
+		t.Error("Uint128FromBig(-1) succeeded")
+	}
+}
+
+func TestSerializeDeserializeOption(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    Option[uint16]
+		expected []byte
+	}{
+		{"none", None[uint16](), []byte{0x00}},
+		{"some zero", Some[uint16](0), []byte{0x01, 0x00}},
+		{"some", Some[uint16](300), []byte{0x01, 0xAC, 0x02}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := Serialize(tt.input)
+			if err != nil {
+				t.Fatalf("Serialize(%v) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(encoded, tt.expected) {
+				t.Errorf("Serialize(%v) = %v, want %v", tt.input, encoded, tt.expected)
+			}
+
+			var decoded Option[uint16]
+			err = Deserialize(encoded, &decoded)
+			if err != nil {
+				t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+			}
+			if decoded != tt.input {
+				t.Errorf("got %v, want %v", decoded, tt.input)
+			}
+		})
+	}
+
+	var decoded Option[uint16]
+	if err := Deserialize([]byte{0x02}, &decoded); err != ErrDeserializeBadOption {
+		t.Errorf("Deserialize([2]) error = %v, want %v", err, ErrDeserializeBadOption)
+	}
+}
+
+type testShape interface{ isTestShape() }
+
+type testCircle struct{ R float32 }
+
+type testRect struct{ W, H uint8 }
+
+type testEmpty struct{}
+
+func (testCircle) isTestShape() {}
+func (*testRect) isTestShape()  {}
+func (testEmpty) isTestShape()  {}
+
+func init() {
+	RegisterEnum[testShape](testCircle{}, &testRect{}, testEmpty{})
+}
+
+func TestSerializeDeserializeEnum(t *testing.T) {
+	type Drawing struct {
+		Shapes []testShape
+		Focus  Option[testShape]
+	}
+
+	tests := []struct {
+		name     string
+		input    Drawing
+		expected []byte
+	}{
+		{"empty", Drawing{Shapes: []testShape{}}, []byte{0x00, 0x00}},
+		{
+			"variants",
+			Drawing{
+				Shapes: []testShape{testEmpty{}, &testRect{W: 3, H: 4}, testCircle{R: 1}},
+				Focus:  Some[testShape](testEmpty{}),
+			},
+			[]byte{0x03, 0x02, 0x01, 0x03, 0x04, 0x00, 0x00, 0x00, 0x80, 0x3F, 0x01, 0x02},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := Serialize(tt.input)
+			if err != nil {
+				t.Fatalf("Serialize(%v) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(encoded, tt.expected) {
+				t.Errorf("Serialize(%v) = %v, want %v", tt.input, encoded, tt.expected)
+			}
+
+			var decoded Drawing
+			err = Deserialize(encoded, &decoded)
+			if err != nil {
+				t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+			}
+			if !reflect.DeepEqual(decoded, tt.input) {
+				t.Errorf("got %v, want %v", decoded, tt.input)
+			}
+		})
+	}
+
+	var shape testShape
+	if err := Deserialize([]byte{0x07}, &shape); !errors.Is(err, ErrDeserializeBadEnum) {
+		t.Errorf("Deserialize([7]) error = %v, want %v", err, ErrDeserializeBadEnum)
+	}
+}
+
+func TestSerializeDeserializeFixint(t *testing.T) {
+	type Register struct {
+		Addr  uint32 `postcard:"fixint=le"`
+		Value int16  `postcard:"fixint=be"`
+		Mask  FixU16BE
+		Delta FixI32LE
+		Count uint32
+	}
+
+	input := Register{Addr: 0x40021000, Value: -2, Mask: 0x00FF, Delta: -1, Count: 300}
+	expected := []byte{
+		0x00, 0x10, 0x02, 0x40,
+		0xFF, 0xFE,
+		0x00, 0xFF,
+		0xFF, 0xFF, 0xFF, 0xFF,
+		0xAC, 0x02,
+	}
+	encoded, err := Serialize(input)
+	if err != nil {
+		t.Fatalf("Serialize(%v) error = %v", input, err)
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Serialize(%v) = %v, want %v", input, encoded, expected)
+	}
+	var decoded Register
+	if err := Deserialize(encoded, &decoded); err != nil {
+		t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+	}
+	if decoded != input {
+		t.Errorf("got %v, want %v", decoded, input)
+	}
+	if err := Deserialize(encoded[:5], &decoded); !errors.Is(err, ErrDeserializeUnexpectedEnd) {
+		t.Errorf("Deserialize(short) error = %v, want %v", err, ErrDeserializeUnexpectedEnd)
+	}
+	if n := SizeOf(FixU64BE(1)); n != 8 {
+		t.Errorf("SizeOf(FixU64BE) = %d, want 8", n)
+	}
+
+	type badWidth struct {
+		N int `postcard:"fixint=le"`
+	}
+	if _, err := Serialize(badWidth{}); err == nil {
+		t.Error("Serialize(fixint int) succeeded")
+	}
+	type badOrder struct {
+		N uint32 `postcard:"fixint=middle"`
+	}
+	if _, err := Serialize(badOrder{}); err == nil {
+		t.Error("Serialize(fixint=middle) succeeded")
+	}
+	// Misspelled options and max on a fixint field used to be ignored.
+	type misspelled struct {
+		N uint32 `postcard:"fixnt=le"`
+	}
+	if _, err := Serialize(misspelled{}); err == nil {
+		t.Error("Serialize(fixnt=le) succeeded")
+	}
+	type bounded struct {
+		N uint32 `postcard:"fixint=le,max=4"`
+	}
+	if _, err := MaxSizeFor[bounded](); err == nil {
+		t.Error("MaxSizeFor(fixint=le,max=4) succeeded")
+	}
+}
+
+func TestSerializeDeserializeChar(t *testing.T) {
+	type Key struct {
+		Code    rune `postcard:"char"`
+		Shifted Char
+		Raw     rune
+	}
+
+	input := Key{Code: 'é', Shifted: '�', Raw: 'A'}
+	expected := []byte{0x02, 0xC3, 0xA9, 0x03, 0xEF, 0xBF, 0xBD, 0x82, 0x01}
+	encoded, err := Serialize(input)
+	if err != nil {
+		t.Fatalf("Serialize(%v) error = %v", input, err)
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Serialize(%v) = %v, want %v", input, encoded, expected)
+	}
+	var decoded Key
+	if err := Deserialize(encoded, &decoded); err != nil {
+		t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+	}
+	if decoded != input {
+		t.Errorf("got %v, want %v", decoded, input)
+	}
+	if n := SizeOf(Char('😀')); n != 5 {
+		t.Errorf("SizeOf(Char) = %d, want 5", n)
+	}
+
+	bad := map[string][]byte{
+		"surrogate": {0x03, 0xED, 0xA0, 0x80},
+		"two chars": {0x02, 'a', 'b'},
+		"empty":     {0x00},
+		"too long":  {0x05, 0xF0, 0x9F, 0x98, 0x80, 0x00},
+		"bad utf-8": {0x01, 0xFF},
+	}
+	for name, data := range bad {
+		var c Char
+		if err := Deserialize(data, &c); err != ErrDeserializeBadChar {
+			t.Errorf("%s: Deserialize(%v) error = %v, want %v", name, data, err, ErrDeserializeBadChar)
+		}
+	}
+	if _, err := Serialize(Char(0xD800)); err == nil {
+		t.Error("Serialize(surrogate) succeeded")
+	}
+}
+
+func TestSerializeDeserializeTuple(t *testing.T) {
+	type Reply struct {
+		Pair  Tuple2[uint8, string]
+		Empty Unit
+		Mixed Tuple3[int16, testShape, []bool]
+	}
+
+	input := Reply{
+		Pair:  NewTuple2[uint8, string](7, "ok"),
+		Mixed: NewTuple3[int16, testShape, []bool](-2, testCircle{R: 1}, []bool{true}),
+	}
+	expected := []byte{0x07, 0x02, 'o', 'k', 0x03, 0x00, 0x00, 0x00, 0x80, 0x3F, 0x01, 0x01}
+	encoded, err := Serialize(input)
+	if err != nil {
+		t.Fatalf("Serialize(%v) error = %v", input, err)
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Serialize(%v) = %v, want %v", input, encoded, expected)
+	}
+	var decoded Reply
+	if err := Deserialize(encoded, &decoded); err != nil {
+		t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+	}
+	if !reflect.DeepEqual(decoded, input) {
+		t.Errorf("got %v, want %v", decoded, input)
+	}
+	if n, s := decoded.Pair.Get(); n != 7 || s != "ok" {
+		t.Errorf("Pair.Get() = %d, %q", n, s)
+	}
+
+	s, err := SchemaFor[Reply]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		name string
+		kind SchemaKind
+	}{
+		{"(u8, String)", SchemaTuple},
+		{"()", SchemaUnit},
+		{"(i16, testShape, Vec<bool>)", SchemaTuple},
+	}
+	for i, f := range s.Fields {
+		if f.Type.Name != want[i].name || f.Type.Kind != want[i].kind {
+			t.Errorf("field %s = %s %v, want %s %v", f.Name, f.Type.Name, f.Type.Kind, want[i].name, want[i].kind)
+		}
+	}
+
+	type node struct{ Kids []Tuple2[string, node] }
+	if _, err := SchemaFor[node](); err == nil {
+		t.Error("SchemaFor(recursive tuple) succeeded")
+	}
+}
+
+type ptrError struct{}
+
+func (*ptrError) Error() string { return "ptrError" }
+
+func TestSerializeDeserializeResult(t *testing.T) {
+	type reply = Result[Tuple2[uint8, string], testShape]
+
+	tests := []struct {
+		name     string
+		input    reply
+		expected []byte
+	}{
+		{"ok", Ok[Tuple2[uint8, string], testShape](NewTuple2[uint8, string](7, "ok")), []byte{0x00, 0x07, 0x02, 'o', 'k'}},
+		{"err", Err[Tuple2[uint8, string]](testShape(&testRect{W: 3, H: 4})), []byte{0x01, 0x01, 0x03, 0x04}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := Serialize(tt.input)
+			if err != nil {
+				t.Fatalf("Serialize(%v) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(encoded, tt.expected) {
+				t.Errorf("Serialize(%v) = %v, want %v", tt.input, encoded, tt.expected)
+			}
+
+			var decoded reply
+			err = Deserialize(encoded, &decoded)
+			if err != nil {
+				t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+			}
+			if !reflect.DeepEqual(decoded, tt.input) {
+				t.Errorf("got %v, want %v", decoded, tt.input)
+			}
+		})
+	}
+
+	var decoded reply
+	if err := Deserialize([]byte{0x02}, &decoded); !errors.Is(err, ErrDeserializeBadEnum) {
+		t.Errorf("Deserialize([2]) error = %v, want %v", err, ErrDeserializeBadEnum)
+	}
+
+	if v, err := Ok[uint8, Unit](5).Unwrap(); v != 5 || err != nil {
+		t.Errorf("Ok(5).Unwrap() = %d, %v", v, err)
+	}
+	_, err := Err[uint8](Unit{}).Unwrap()
+	var resultErr *ResultError[Unit]
+	if !errors.As(err, &resultErr) {
+		t.Errorf("Err(()).Unwrap() error = %#v, want *ResultError[Unit]", err)
+	}
+	if _, err := Err[uint8](ErrDeserializeBadBool).Unwrap(); err != ErrDeserializeBadBool {
+		t.Errorf("Err(error).Unwrap() error = %v, want %v", err, ErrDeserializeBadBool)
+	}
+	if _, err := Err[uint8, error](nil).Unwrap(); err != ErrNilErr {
+		t.Errorf("Err(nil error).Unwrap() error = %#v, want %v", err, ErrNilErr)
+	}
+	if _, err := Err[uint8]((*ptrError)(nil)).Unwrap(); err != ErrNilErr {
+		t.Errorf("Err(nil *ptrError).Unwrap() error = %#v, want %v", err, ErrNilErr)
+	}
+	if _, err := Err[uint8](&ptrError{}).Unwrap(); err == nil || err == ErrNilErr {
+		t.Errorf("Err(&ptrError{}).Unwrap() error = %#v, want the *ptrError", err)
+	}
+
+	s, err := SchemaFor[Result[uint8, Unit]]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "Result<u8, ()>" || s.Kind != SchemaEnum || len(s.Variants) != 2 ||
+		s.Variants[0].Name != "Ok" || s.Variants[1].Elems[0].Kind != SchemaUnit {
+		t.Errorf("SchemaFor(Result[uint8, Unit]) = %+v", s)
+	}
+}
+
+func TestSerializeDeserializeTime(t *testing.T) {
+	type Sample struct {
+		Took  time.Duration
+		At    time.Time
+		AtMs  time.Time `postcard:"unix=ms"`
+		Timer Duration
+	}
+
+	input := Sample{
+		Took:  1500 * time.Millisecond,
+		At:    time.Unix(1700000000, 0).UTC(),
+		AtMs:  time.UnixMilli(1700000000123).UTC(),
+		Timer: Duration(2 * time.Second),
+	}
+	expected := []byte{
+		0x01, 0x80, 0xCA, 0xB5, 0xEE, 0x01,
+		0x80, 0xC4, 0x9F, 0xD5, 0x0C,
+		0xF6, 0xA1, 0xAB, 0xFE, 0xF9, 0x62,
+		0x02, 0x00,
+	}
+	encoded, err := Serialize(input)
+	if err != nil {
+		t.Fatalf("Serialize(%v) error = %v", input, err)
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Serialize(%v) = %v, want %v", input, encoded, expected)
+	}
+	if n := SizeOf(input.Timer); n != 2 {
+		t.Errorf("SizeOf(%v) = %d, want 2", input.Timer, n)
+	}
+	var decoded Sample
+	if err := Deserialize(encoded, &decoded); err != nil {
+		t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+	}
+	if !reflect.DeepEqual(decoded, input) {
+		t.Errorf("got %v, want %v", decoded, input)
+	}
+
+	if _, err := Serialize(-time.Second); err == nil {
+		t.Error("Serialize(-1s) succeeded")
+	}
+	// Nanoseconds past a second carry into the seconds, as in Rust.
+	var d time.Duration
+	if err := Deserialize([]byte{0x01, 0x80, 0xDE, 0xA0, 0xCB, 0x05}, &d); err != nil || d != 2500*time.Millisecond {
+		t.Errorf("Deserialize(1s + 1.5e9ns) = %v, %v, want 2.5s", d, err)
+	}
+	if err := Deserialize([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x01, 0x00}, &d); err == nil {
+		t.Error("Deserialize(2^35 s) succeeded")
+	}
+}
+
+func TestSerializeUnexportedStruct(t *testing.T) {
+	type hidden struct{ a, b uint8 }
+	if _, err := Serialize(hidden{1, 2}); err == nil {
+		t.Error("Serialize(struct with only unexported fields) succeeded")
+	}
+	var h hidden
+	if err := Deserialize([]byte{0x01}, &h); err == nil {
+		t.Error("Deserialize(struct with only unexported fields) succeeded")
+	}
+	if _, err := SchemaFor[hidden](); err == nil {
+		t.Error("SchemaFor(struct with only unexported fields) succeeded")
+	}
+	if encoded, err := Serialize(struct{ Unit }{}); err != nil || len(encoded) != 0 {
+		t.Errorf("Serialize(empty struct) = %v, %v", encoded, err)
+	}
+}
+
+func TestSerializeDeserializeComplex(t *testing.T) {
+	type IQ []complex64
+	type Capture struct {
+		Center  complex128
+		Samples IQ
+		Wide    []complex128
+	}
+
+	input := Capture{
+		Center:  complex(0.5, -1),
+		Samples: IQ{complex(1, 2), complex(-0.5, 0)},
+		Wide:    []complex128{complex(2, 0.25)},
+	}
+	expected := []byte{
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xE0, 0x3F, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0, 0xBF,
+		0x02,
+		0x00, 0x00, 0x80, 0x3F, 0x00, 0x00, 0x00, 0x40,
+		0x00, 0x00, 0x00, 0xBF, 0x00, 0x00, 0x00, 0x00,
+		0x01,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xD0, 0x3F,
+	}
+	encoded, err := Serialize(input)
+	if err != nil {
+		t.Fatalf("Serialize(%v) error = %v", input, err)
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Serialize(%v) = %v, want %v", input, encoded, expected)
+	}
+	var decoded Capture
+	if err := Deserialize(encoded, &decoded); err != nil {
+		t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+	}
+	if !reflect.DeepEqual(decoded, input) {
+		t.Errorf("got %v, want %v", decoded, input)
+	}
+
+	// The bulk path must agree with element-by-element encoding.
+	arr := [2]complex64{complex(1, 2), complex(-0.5, 0)}
+	fromArray, err := Serialize(arr)
+	if err != nil {
+		t.Fatalf("Serialize(%v) error = %v", arr, err)
+	}
+	if !reflect.DeepEqual(fromArray, expected[17:33]) {
+		t.Errorf("Serialize(%v) = %v, want %v", arr, fromArray, expected[17:33])
+	}
+
+	var short []complex64
+	if err := Deserialize([]byte{0x02, 0x00, 0x00, 0x80, 0x3F, 0x00, 0x00, 0x00, 0x40}, &short); err != ErrDeserializeUnexpectedEnd {
+		t.Errorf("Deserialize(truncated) error = %v, want %v", err, ErrDeserializeUnexpectedEnd)
+	}
+}
+
+func TestSerializeDeserializeNetip(t *testing.T) {
+	type Config struct {
+		Gateway netip.Addr
+		Server  netip.AddrPort
+		Subnet  netip.Prefix
+		DNS     []netip.Addr
+	}
+
+	input := Config{
+		Gateway: netip.MustParseAddr("192.168.1.1"),
+		Server:  netip.MustParseAddrPort("[2001:db8::1]:8080"),
+		Subnet:  netip.MustParsePrefix("10.0.0.0/8"),
+		DNS:     []netip.Addr{netip.MustParseAddr("::ffff:1.1.1.1")},
+	}
+	expected := []byte{
+		0x00, 192, 168, 1, 1,
+		0x01, 0x20, 0x01, 0x0D, 0xB8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x01, 0x90, 0x3F,
+		0x00, 10, 0, 0, 0, 8,
+		0x01, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xFF, 0xFF, 1, 1, 1, 1,
+	}
+	encoded, err := Serialize(input)
+	if err != nil {
+		t.Fatalf("Serialize(%v) error = %v", input, err)
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Serialize(%v) = %v, want %v", input, encoded, expected)
+	}
+	var decoded Config
+	if err := Deserialize(encoded, &decoded); err != nil {
+		t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+	}
+	if !reflect.DeepEqual(decoded, input) {
+		t.Errorf("got %v, want %v", decoded, input)
+	}
+
+	for _, v := range []interface{}{netip.Addr{}, netip.MustParseAddr("fe80::1%eth0"), netip.Prefix{}} {
+		if _, err := Serialize(v); err == nil {
+			t.Errorf("Serialize(%#v) succeeded", v)
+		}
+	}
+	var prefix netip.Prefix
+	if err := Deserialize([]byte{0x00, 10, 0, 0, 0, 33}, &prefix); err == nil {
+		t.Error("Deserialize(10.0.0.0/33) succeeded")
+	}
+	var addr netip.Addr
+	if err := Deserialize([]byte{0x02}, &addr); !errors.Is(err, ErrDeserializeBadEnum) {
+		t.Errorf("Deserialize([2]) error = %v, want %v", err, ErrDeserializeBadEnum)
+	}
+
+	s, err := SchemaFor[netip.AddrPort]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "SocketAddr" || s.Kind != SchemaEnum || len(s.Variants[1].Elems[0].Elems) != 2 {
+		t.Errorf("SchemaFor(netip.AddrPort) = %+v", s)
+	}
+}
+
+func TestSerializeDeserializeUUID(t *testing.T) {
+	type Device struct {
+		ID     UUID
+		Serial [4]byte
+		Key    [4]byte `postcard:"bytes"`
+		Blob   []byte  `postcard:"bytes"`
+	}
+
+	id, err := ParseUUID("67e55044-10b1-426f-9247-bb680e5fe0c8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.String() != "67e55044-10b1-426f-9247-bb680e5fe0c8" {
+		t.Errorf("String() = %s", id)
+	}
+	input := Device{ID: id, Serial: [4]byte{1, 2, 3, 4}, Key: [4]byte{5, 6, 7, 8}, Blob: []byte{9}}
+	expected := []byte{
+		0x10, 0x67, 0xE5, 0x50, 0x44, 0x10, 0xB1, 0x42, 0x6F, 0x92, 0x47, 0xBB, 0x68, 0x0E, 0x5F, 0xE0, 0xC8,
+		0x01, 0x02, 0x03, 0x04,
+		0x04, 0x05, 0x06, 0x07, 0x08,
+		0x01, 0x09,
+	}
+	encoded, err := Serialize(input)
+	if err != nil {
+		t.Fatalf("Serialize(%v) error = %v", input, err)
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Serialize(%v) = %v, want %v", input, encoded, expected)
+	}
+	if n := id.SizePostcard(); n != 17 {
+		t.Errorf("SizePostcard() = %d, want 17", n)
+	}
+	var decoded Device
+	if err := Deserialize(encoded, &decoded); err != nil {
+		t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+	}
+	if !reflect.DeepEqual(decoded, input) {
+		t.Errorf("got %v, want %v", decoded, input)
+	}
+
+	// A [u8; 16] is not a Uuid: no length prefix.
+	plain, err := Serialize([16]byte(id))
+	if err != nil || !reflect.DeepEqual(plain, expected[1:17]) {
+		t.Errorf("Serialize([16]byte) = %v, %v, want %v", plain, err, expected[1:17])
+	}
+
+	var short UUID
+	if err := Deserialize([]byte{0x02, 0x01, 0x02}, &short); !errors.Is(err, ErrDeserializeBadEncoding) {
+		t.Errorf("Deserialize(2-byte UUID) error = %v, want %v", err, ErrDeserializeBadEncoding)
+	}
+	if _, err := ParseUUID("67e55044-10b1-426f-9247-bb680e5fe0cz"); err == nil {
+		t.Error("ParseUUID(bad digit) succeeded")
+	}
+	type bad struct {
+		N uint32 `postcard:"bytes"`
+	}
+	if _, err := Serialize(bad{}); err == nil {
+		t.Error("Serialize(bytes on uint32) succeeded")
+	}
+}
+
+func TestSerializeDeserializeMax(t *testing.T) {
+	type Config struct {
+		Name  String[N4]
+		Data  Vec[uint16, N2]
+		Tags  []string       `postcard:"max=2"`
+		Raw   []byte         `postcard:"max=3"`
+		Attrs map[uint8]bool `postcard:"max=1"`
+		Nick  Option[string] `postcard:"max=2"`
+	}
+
+	input := Config{
+		Name:  "abcd",
+		Data:  Vec[uint16, N2]{1, 300},
+		Tags:  []string{"x"},
+		Raw:   []byte{7, 8, 9},
+		Attrs: map[uint8]bool{5: true},
+		Nick:  Some("hi"),
+	}
+	expected := []byte{
+		0x04, 'a', 'b', 'c', 'd',
+		0x02, 0x01, 0xAC, 0x02,
+		0x01, 0x01, 'x',
+		0x03, 0x07, 0x08, 0x09,
+		0x01, 0x05, 0x01,
+		0x01, 0x02, 'h', 'i',
+	}
+	encoded, err := Serialize(input)
+	if err != nil {
+		t.Fatalf("Serialize(%v) error = %v", input, err)
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Serialize(%v) = %v, want %v", input, encoded, expected)
+	}
+	var decoded Config
+	if err := Deserialize(encoded, &decoded); err != nil {
+		t.Fatalf("Deserialize(%v) error = %v", encoded, err)
+	}
+	if !reflect.DeepEqual(decoded, input) {
+		t.Errorf("got %+v, want %+v", decoded, input)
+	}
+
+	long := []Config{
+		{Name: "abcde"},
+		{Data: Vec[uint16, N2]{1, 2, 3}},
+		{Tags: []string{"a", "b", "c"}},
+		{Raw: []byte{1, 2, 3, 4}},
+		{Attrs: map[uint8]bool{1: true, 2: false}},
+		{Nick: Some("abc")},
+	}
+	for _, v := range long {
+		if _, err := Serialize(v); !errors.Is(err, ErrCapacityExceeded) {
+			t.Errorf("Serialize(%+v) error = %v, want %v", v, err, ErrCapacityExceeded)
+		}
+	}
+
+	// The length is checked before the elements, which are not there.
+	for _, data := range [][]byte{
+		{0x05},
+		{0x00, 0x03},
+		{0x00, 0x00, 0x03},
+		{0x00, 0x00, 0x00, 0x04},
+		{0x00, 0x00, 0x00, 0x00, 0x02},
+		{0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x03},
+	} {
+		if err := Deserialize(data, &decoded); !errors.Is(err, ErrCapacityExceeded) {
+			t.Errorf("Deserialize(%v) error = %v, want %v", data, err, ErrCapacityExceeded)
+		}
+	}
+
+	type bad struct {
+		N uint32 `postcard:"max=4"`
+	}
+	if _, err := Serialize(bad{}); err == nil {
+		t.Error("Serialize(max on uint32) succeeded")
+	}
+	type zero struct {
+		S string `postcard:"max=0"`
+	}
+	if _, err := Serialize(zero{}); err == nil {
+		t.Error("Serialize(max=0) succeeded")
+	}
+}
+
+func TestSerializeDeserializeMaxPointer(t *testing.T) {
+	type Config struct {
+		Nick *Option[string] `postcard:"max=4"`
+		Data *Vec[uint8, N2]
+	}
+
+	input := Config{Nick: &Option[string]{Value: "abc", Valid: true}}
+	expected := []byte{0x01, 0x03, 'a', 'b', 'c', 0x00}
+	encoded, err := Serialize(input)
+	if err != nil {
+		t.Fatalf("Serialize(%+v) error = %v", input, err)
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Serialize(%+v) = %v, want %v", input, encoded, expected)
+	}
+	var decoded struct {
+		Nick *Option[string] `postcard:"max=4"`
+	}
+	if err := Deserialize(encoded[:5], &decoded); err != nil {
+		t.Fatalf("Deserialize(%v) error = %v", encoded[:5], err)
+	}
+	if decoded.Nick == nil || *decoded.Nick != *input.Nick {
+		t.Errorf("Deserialize(%v) = %+v, want %+v", encoded[:5], decoded.Nick, input.Nick)
+	}
+
+	if _, err := Serialize(Config{}); err != nil {
+		t.Errorf("Serialize(nil pointers) error = %v", err)
+	}
+	long := Config{Nick: &Option[string]{Value: "abcde", Valid: true}}
+	if _, err := Serialize(long); !errors.Is(err, ErrCapacityExceeded) {
+		t.Errorf("Serialize(%+v) error = %v, want %v", long, err, ErrCapacityExceeded)
+	}
+	if err := Deserialize([]byte{0x01, 0x05}, &decoded); !errors.Is(err, ErrCapacityExceeded) {
+		t.Errorf("Deserialize([1 5]) error = %v, want %v", err, ErrCapacityExceeded)
+	}
+
+	n, err := MaxSizeFor[Config]()
+	if err != nil {
+		t.Fatalf("MaxSizeFor[Config]() error = %v", err)
+	}
+	if n != 9 {
+		t.Errorf("MaxSizeFor[Config]() = %d, want 9", n)
+	}
+}
+
+func TestMaxSizeOf(t *testing.T) {
+	type Packet struct {
+		Seq  uint32
+		Kind Option[int8]
+		Name String[N16]
+		Data Vec[FixU16LE, N128]
+		Raw  []byte `postcard:"max=200"`
+		Peer netip.AddrPort
+		Nick Option[string] `postcard:"max=8"`
+	}
+
+	n, err := MaxSizeFor[Packet]()
+	if err != nil {
+		t.Fatalf("MaxSizeFor[Packet]() error = %v", err)
+	}
+	if n != 514 {
+		t.Errorf("MaxSizeFor[Packet]() = %d, want 514", n)
+	}
+	largest := Packet{
+		Seq:  math.MaxUint32,
+		Kind: Some[int8](-1),
+		Name: String[N16](strings.Repeat("x", 16)),
+		Data: make(Vec[FixU16LE, N128], 128),
+		Raw:  make([]byte, 200),
+		Peer: netip.MustParseAddrPort("[::1]:65535"),
+		Nick: Some(strings.Repeat("x", 8)),
+	}
+	encoded, err := Serialize(largest)
+	if err != nil {
+		t.Fatalf("Serialize(largest) error = %v", err)
+	}
+	if len(encoded) != n {
+		t.Errorf("len(Serialize(largest)) = %d, want %d", len(encoded), n)
+	}
+
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf([]int(nil)),
+		reflect.TypeOf(""),
+		reflect.TypeOf(map[string]int(nil)),
+		reflect.TypeOf(struct{ Name string }{}),
+	} {
+		if _, err := MaxSizeOf(typ); err == nil {
+			t.Errorf("MaxSizeOf(%v) succeeded", typ)
+		}
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	type sample struct {
+		DeviceID uint16
+		Label    Option[string]
+		Readings []float32
+		Pos      [2]int8
+		Shape    testShape
+		skipped  int
+	}
+	s, err := SchemaFor[sample]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Kind != SchemaStruct || len(s.Fields) != 5 {
+		t.Fatalf("SchemaFor(sample) = %+v", s)
+	}
+	names := []string{"device_id", "label", "readings", "pos", "shape"}
+	kinds := []SchemaKind{SchemaU16, SchemaOption, SchemaSeq, SchemaTuple, SchemaEnum}
+	for i, f := range s.Fields {
+		if f.Name != names[i] || f.Type.Kind != kinds[i] {
+			t.Errorf("field %d = %s %v, want %s %v", i, f.Name, f.Type.Kind, names[i], kinds[i])
+		}
+	}
+	variants := s.Fields[4].Type.Variants
+	wantVariants := []VariantKind{VariantStruct, VariantStruct, VariantUnit}
+	if len(variants) != len(wantVariants) {
+		t.Fatalf("shape has %d variants, want %d", len(variants), len(wantVariants))
+	}
+	for i, v := range variants {
+		if v.Kind != wantVariants[i] {
+			t.Errorf("variant %s kind = %v, want %v", v.Name, v.Kind, wantVariants[i])
+		}
+	}
+
+	type node struct{ Next []node }
+	if _, err := SchemaFor[node](); err == nil {
+		t.Error("SchemaFor(recursive) succeeded")
+	}
+}
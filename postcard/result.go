@@ -0,0 +1,99 @@
+package postcard
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Result models a Rust Result<T, E>: an enum whose variant 0 is Ok(T) and
+// variant 1 is Err(E).
+type Result[T, E any] struct {
+	Ok    T
+	Err   E
+	IsErr bool
+}
+
+func Ok[T, E any](v T) Result[T, E] {
+	return Result[T, E]{Ok: v}
+}
+
+func Err[T, E any](e E) Result[T, E] {
+	return Result[T, E]{Err: e, IsErr: true}
+}
+
+// ResultError is the error Unwrap returns for an Err whose value is not
+// itself an error.
+type ResultError[E any] struct {
+	Value E
+}
+
+func (e *ResultError[E]) Error() string {
+	return fmt.Sprintf("postcard: Err(%v)", e.Value)
+}
+
+// ErrNilErr is what Unwrap returns for an Err holding a nil error, so that
+// an Err never unwraps to a nil error.
+var ErrNilErr = errors.New("postcard: Err holds a nil error")
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Unwrap returns the Ok value, or an error for Err: the value itself if E is
+// an error, ErrNilErr if that error is nil, otherwise a *ResultError[E]
+// holding it.
+func (r Result[T, E]) Unwrap() (T, error) {
+	if !r.IsErr {
+		return r.Ok, nil
+	}
+	var zero T
+	if !typeOf[E]().Implements(errorType) {
+		return zero, &ResultError[E]{Value: r.Err}
+	}
+	err, _ := any(r.Err).(error)
+	if err == nil {
+		return zero, ErrNilErr
+	}
+	// A nil pointer, map, slice, func or chan E makes a non-nil error that
+	// is nil all the same.
+	switch v := reflect.ValueOf(err); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return zero, ErrNilErr
+		}
+	}
+	return zero, err
+}
+
+func (r Result[T, E]) MarshalPostcard(s *Serializer) error {
+	if r.IsErr {
+		if err := s.SerializeEnum(1, nil); err != nil {
+			return err
+		}
+		return s.serializeReflect(reflect.ValueOf(&r.Err).Elem())
+	}
+	if err := s.SerializeEnum(0, nil); err != nil {
+		return err
+	}
+	return s.serializeReflect(reflect.ValueOf(&r.Ok).Elem())
+}
+
+func (r *Result[T, E]) UnmarshalPostcard(d *Deserializer) error {
+	var variant uint32
+	if err := d.DeserializeEnum(&variant, nil); err != nil {
+		return err
+	}
+	switch variant {
+	case 0:
+		*r = Result[T, E]{}
+		return d.DeserializeValue(&r.Ok)
+	case 1:
+		*r = Result[T, E]{IsErr: true}
+		return d.DeserializeValue(&r.Err)
+	default:
+		return ErrDeserializeBadEnum
+	}
+}
+
+func (Result[T, E]) resultElems() (ok, err reflect.Type) {
+	return typeOf[T](), typeOf[E]()
+}
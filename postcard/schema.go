@@ -0,0 +1,448 @@
+package postcard
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// SchemaKind is a type of the serde data model. The values match the
+// discriminants of postcard-schema's DataModelType, which postcard-rpc hashes.
+type SchemaKind uint8
+
+const (
+	SchemaBool SchemaKind = iota
+	SchemaI8
+	SchemaU8
+	SchemaI16
+	SchemaI32
+	SchemaI64
+	SchemaI128
+	SchemaU16
+	SchemaU32
+	SchemaU64
+	SchemaU128
+	SchemaUsize
+	SchemaIsize
+	SchemaF32
+	SchemaF64
+	SchemaChar
+	SchemaString
+	SchemaByteArray
+	SchemaOption
+	SchemaUnit
+	SchemaUnitStruct
+	SchemaNewtypeStruct
+	SchemaSeq
+	SchemaTuple
+	SchemaTupleStruct
+	SchemaMap
+	SchemaStruct
+	SchemaEnum
+	SchemaSchema
+)
+
+type VariantKind uint8
+
+const (
+	VariantUnit VariantKind = iota
+	VariantNewtype
+	VariantTuple
+	VariantStruct
+)
+
+// Schema describes a named type in the serde data model, like
+// postcard-schema's NamedType.
+type Schema struct {
+	Name string
+	Kind SchemaKind
+	// Elems holds the inner type of Option, NewtypeStruct and Seq, the key
+	// and value of Map, and the members of Tuple and TupleStruct.
+	Elems []*Schema
+	// Fields holds the fields of Struct.
+	Fields []SchemaField
+	// Variants holds the variants of Enum.
+	Variants []SchemaVariant
+}
+
+type SchemaField struct {
+	Name string
+	Type *Schema
+}
+
+type SchemaVariant struct {
+	Name   string
+	Kind   VariantKind
+	Elems  []*Schema
+	Fields []SchemaField
+}
+
+// SchemaDescriber is implemented by types whose encoding reflection cannot
+// see, typically those with MarshalPostcard methods.
+type SchemaDescriber interface {
+	PostcardSchema() (*Schema, error)
+}
+
+// VariantDescriber is implemented by enum variant types whose Rust variant
+// reflection cannot see: one named differently from the Go type, such as
+// CommandPing for Command::Ping, or a newtype or tuple variant held in a
+// struct. postcard-rs2go writes these methods with the helpers below.
+type VariantDescriber interface {
+	PostcardVariant() (SchemaVariant, error)
+}
+
+var (
+	varintType           = reflect.TypeOf(Varint(0))
+	schemaDescriberType  = reflect.TypeOf((*SchemaDescriber)(nil)).Elem()
+	variantDescriberType = reflect.TypeOf((*VariantDescriber)(nil)).Elem()
+	optionType           = reflect.TypeOf((*interface{ optionElem() reflect.Type })(nil)).Elem()
+	tupleType            = reflect.TypeOf((*interface{ tupleElems() []reflect.Type })(nil)).Elem()
+	resultType           = reflect.TypeOf((*interface{ resultElems() (ok, err reflect.Type) })(nil)).Elem()
+	unitType             = reflect.TypeOf(Unit{})
+)
+
+var primitiveSchemas = map[reflect.Kind]*Schema{
+	reflect.Bool:    {Name: "bool", Kind: SchemaBool},
+	reflect.Int8:    {Name: "i8", Kind: SchemaI8},
+	reflect.Int16:   {Name: "i16", Kind: SchemaI16},
+	reflect.Int32:   {Name: "i32", Kind: SchemaI32},
+	reflect.Int64:   {Name: "i64", Kind: SchemaI64},
+	reflect.Int:     {Name: "i64", Kind: SchemaI64},
+	reflect.Uint8:   {Name: "u8", Kind: SchemaU8},
+	reflect.Uint16:  {Name: "u16", Kind: SchemaU16},
+	reflect.Uint32:  {Name: "u32", Kind: SchemaU32},
+	reflect.Uint64:  {Name: "u64", Kind: SchemaU64},
+	reflect.Uint:    {Name: "u64", Kind: SchemaU64},
+	reflect.Float32: {Name: "f32", Kind: SchemaF32},
+	reflect.Float64: {Name: "f64", Kind: SchemaF64},
+	reflect.String:  {Name: "String", Kind: SchemaString},
+	reflect.Complex64: {Name: "Complex<f32>", Kind: SchemaTuple, Elems: []*Schema{
+		{Name: "f32", Kind: SchemaF32}, {Name: "f32", Kind: SchemaF32},
+	}},
+	reflect.Complex128: {Name: "Complex<f64>", Kind: SchemaTuple, Elems: []*Schema{
+		{Name: "f64", Kind: SchemaF64}, {Name: "f64", Kind: SchemaF64},
+	}},
+}
+
+// SchemaFor returns the schema of T. See SchemaOf.
+func SchemaFor[T any]() (*Schema, error) {
+	return SchemaOf(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// SchemaOf derives the schema of t from the way SerializeValue encodes it,
+// naming fields the way Rust would (snake_case). int and uint are described
+// as i64 and u64, slices as Seq, arrays, TupleN and complex numbers as
+// Tuple, Result as an Ok/Err enum, Unit and anonymous empty structs as Unit
+// and named ones as UnitStruct. Rust constructs Go cannot express, such as
+// newtype structs or tuple variants, need a SchemaDescriber or, for enum
+// variants, a VariantDescriber.
+func SchemaOf(t reflect.Type) (*Schema, error) {
+	return schemaOf(t, map[reflect.Type]bool{})
+}
+
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) (*Schema, error) {
+	if t.Implements(schemaDescriberType) {
+		return reflect.Zero(t).Interface().(SchemaDescriber).PostcardSchema()
+	}
+	if reflect.PtrTo(t).Implements(schemaDescriberType) {
+		return reflect.New(t).Interface().(SchemaDescriber).PostcardSchema()
+	}
+	if t == varintType {
+		return &Schema{Name: "u64", Kind: SchemaU64}, nil
+	}
+	if t == durationType {
+		return Duration(0).PostcardSchema()
+	}
+	if t == timeType {
+		return UnixSeconds{}.PostcardSchema()
+	}
+	if t == addrType || t == addrPortType || t == prefixType {
+		return netipSchema(t), nil
+	}
+	if t == unitType {
+		return &Schema{Name: "()", Kind: SchemaUnit}, nil
+	}
+	if visiting[t] {
+		return nil, fmt.Errorf("recursive type %v has no schema", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	if t.Implements(optionType) {
+		elemType := reflect.Zero(t).Interface().(interface{ optionElem() reflect.Type }).optionElem()
+		elem, err := schemaOf(elemType, visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Name: "Option<" + elem.Name + ">", Kind: SchemaOption, Elems: []*Schema{elem}}, nil
+	}
+	if t.Implements(tupleType) {
+		s := &Schema{Kind: SchemaTuple}
+		var names []string
+		for _, et := range reflect.Zero(t).Interface().(interface{ tupleElems() []reflect.Type }).tupleElems() {
+			elem, err := schemaOf(et, visiting)
+			if err != nil {
+				return nil, err
+			}
+			s.Elems = append(s.Elems, elem)
+			names = append(names, elem.Name)
+		}
+		s.Name = "(" + strings.Join(names, ", ") + ")"
+		return s, nil
+	}
+	if t.Implements(resultType) {
+		okType, errType := reflect.Zero(t).Interface().(interface{ resultElems() (ok, err reflect.Type) }).resultElems()
+		okSchema, err := schemaOf(okType, visiting)
+		if err != nil {
+			return nil, err
+		}
+		errSchema, err := schemaOf(errType, visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{
+			Name: "Result<" + okSchema.Name + ", " + errSchema.Name + ">",
+			Kind: SchemaEnum,
+			Variants: []SchemaVariant{
+				{Name: "Ok", Kind: VariantNewtype, Elems: []*Schema{okSchema}},
+				{Name: "Err", Kind: VariantNewtype, Elems: []*Schema{errSchema}},
+			},
+		}, nil
+	}
+	if p, ok := primitiveSchemas[t.Kind()]; ok {
+		s := *p
+		return &s, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		elem, err := schemaOf(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Name: "Vec<" + elem.Name + ">", Kind: SchemaSeq, Elems: []*Schema{elem}}, nil
+	case reflect.Array:
+		elem, err := schemaOf(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		elems := make([]*Schema, t.Len())
+		for i := range elems {
+			elems[i] = elem
+		}
+		return &Schema{Name: fmt.Sprintf("[%s; %d]", elem.Name, t.Len()), Kind: SchemaTuple, Elems: elems}, nil
+	case reflect.Map:
+		key, err := schemaOf(t.Key(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		val, err := schemaOf(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("Map<%s, %s>", key.Name, val.Name)
+		return &Schema{Name: name, Kind: SchemaMap, Elems: []*Schema{key, val}}, nil
+	case reflect.Struct:
+		fields, err := schemaFields(t, visiting)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkExported(t); err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			if t.Name() == "" {
+				return &Schema{Name: "()", Kind: SchemaUnit}, nil
+			}
+			return &Schema{Name: t.Name(), Kind: SchemaUnitStruct}, nil
+		}
+		return &Schema{Name: t.Name(), Kind: SchemaStruct, Fields: fields}, nil
+	case reflect.Interface:
+		info := lookupEnum(t)
+		if info == nil {
+			return nil, fmt.Errorf("interface %v is not a registered enum", t)
+		}
+		s := &Schema{Name: t.Name(), Kind: SchemaEnum}
+		for _, vt := range info.variants {
+			v, err := schemaVariant(vt, visiting)
+			if err != nil {
+				return nil, err
+			}
+			s.Variants = append(s.Variants, v)
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("type %v has no schema", t)
+}
+
+func schemaFields(t reflect.Type, visiting map[reflect.Type]bool) ([]SchemaField, error) {
+	var fields []SchemaField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		ft, err := schemaOfField(f, visiting)
+		if err != nil {
+			return nil, fmt.Errorf("%v.%s: %w", t, f.Name, err)
+		}
+		fields = append(fields, SchemaField{Name: snakeCase(f.Name), Type: ft})
+	}
+	return fields, nil
+}
+
+// schemaOfField is schemaOf for a struct field, whose tag may change the
+// encoding of its type.
+func schemaOfField(f reflect.StructField, visiting map[reflect.Type]bool) (*Schema, error) {
+	tag, err := parseFieldTag(f)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case tag.Char:
+		return Char(0).PostcardSchema()
+	case tag.Bytes && isByteArray(f.Type):
+		return &Schema{Name: "bytes", Kind: SchemaByteArray}, nil
+	}
+	return schemaOf(f.Type, visiting)
+}
+
+// schemaVariant describes an enum variant the way postcard-rustgen writes
+// it: empty structs are unit variants, other structs are struct variants and
+// everything else is a newtype variant.
+func schemaVariant(vt reflect.Type, visiting map[reflect.Type]bool) (SchemaVariant, error) {
+	if vt.Kind() == reflect.Ptr {
+		vt = vt.Elem()
+	}
+	if vt.Implements(variantDescriberType) {
+		return reflect.Zero(vt).Interface().(VariantDescriber).PostcardVariant()
+	}
+	if reflect.PtrTo(vt).Implements(variantDescriberType) {
+		return reflect.New(vt).Interface().(VariantDescriber).PostcardVariant()
+	}
+	v := SchemaVariant{Name: vt.Name()}
+	if vt.Kind() == reflect.Struct && !vt.Implements(schemaDescriberType) && !reflect.PtrTo(vt).Implements(schemaDescriberType) {
+		fields, err := schemaFields(vt, visiting)
+		if err != nil {
+			return v, err
+		}
+		if len(fields) > 0 {
+			v.Kind = VariantStruct
+			v.Fields = fields
+		}
+		return v, nil
+	}
+	elem, err := schemaOf(vt, visiting)
+	if err != nil {
+		return v, err
+	}
+	v.Kind = VariantNewtype
+	v.Elems = []*Schema{elem}
+	return v, nil
+}
+
+// NewtypeStructSchema describes a Rust newtype struct called name that wraps
+// a T.
+func NewtypeStructSchema[T any](name string) (*Schema, error) {
+	elem, err := SchemaFor[T]()
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{Name: name, Kind: SchemaNewtypeStruct, Elems: []*Schema{elem}}, nil
+}
+
+// TupleStructSchema describes a Rust tuple struct called name whose members
+// are the exported fields of the struct T, in order.
+func TupleStructSchema[T any](name string) (*Schema, error) {
+	elems, err := fieldSchemas(typeOf[T]())
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{Name: name, Kind: SchemaTupleStruct, Elems: elems}, nil
+}
+
+// UnitEnumSchema describes a Rust enum called name with only unit variants,
+// which Go holds as an integer type with a constant per variant.
+func UnitEnumSchema(name string, variants ...string) *Schema {
+	s := &Schema{Name: name, Kind: SchemaEnum}
+	for _, v := range variants {
+		s.Variants = append(s.Variants, UnitVariantSchema(v))
+	}
+	return s
+}
+
+// UnitVariantSchema describes a unit variant called name.
+func UnitVariantSchema(name string) SchemaVariant {
+	return SchemaVariant{Name: name, Kind: VariantUnit}
+}
+
+// NewtypeVariantSchema describes a newtype variant called name that holds a
+// T.
+func NewtypeVariantSchema[T any](name string) (SchemaVariant, error) {
+	elem, err := SchemaFor[T]()
+	if err != nil {
+		return SchemaVariant{}, err
+	}
+	return SchemaVariant{Name: name, Kind: VariantNewtype, Elems: []*Schema{elem}}, nil
+}
+
+// TupleVariantSchema describes a tuple variant called name whose members are
+// the exported fields of the struct T, in order.
+func TupleVariantSchema[T any](name string) (SchemaVariant, error) {
+	elems, err := fieldSchemas(typeOf[T]())
+	if err != nil {
+		return SchemaVariant{}, err
+	}
+	return SchemaVariant{Name: name, Kind: VariantTuple, Elems: elems}, nil
+}
+
+// StructVariantSchema describes a struct variant called name with the
+// exported fields of the struct T.
+func StructVariantSchema[T any](name string) (SchemaVariant, error) {
+	t := typeOf[T]()
+	if t.Kind() != reflect.Struct {
+		return SchemaVariant{}, fmt.Errorf("struct variant %s needs a struct, got %v", name, t)
+	}
+	fields, err := schemaFields(t, map[reflect.Type]bool{})
+	if err != nil {
+		return SchemaVariant{}, err
+	}
+	return SchemaVariant{Name: name, Kind: VariantStruct, Fields: fields}, nil
+}
+
+// fieldSchemas returns the schemas of the exported fields of the struct t.
+func fieldSchemas(t reflect.Type) ([]*Schema, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("tuple members need a struct, got %v", t)
+	}
+	fields, err := schemaFields(t, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	elems := make([]*Schema, len(fields))
+	for i, f := range fields {
+		elems[i] = f.Type
+	}
+	return elems, nil
+}
+
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -2,8 +2,9 @@ package postcard
 
 import (
 	"fmt"
+	"net/netip"
 	"reflect"
-	"unicode/utf8"
+	"time"
 )
 
 type Serializer struct {
@@ -158,9 +159,12 @@ func (s *Serializer) SerializeSlice(v interface{}) error {
 	if err := s.pushVarintUint(uint(val.Len())); err != nil {
 		return err
 	}
+	if s.serializeComplexSlice(val) {
+		return nil
+	}
 
 	for i := 0; i < val.Len(); i++ {
-		if err := s.SerializeValue(val.Index(i).Interface()); err != nil {
+		if err := s.serializeReflect(val.Index(i)); err != nil {
 			return err
 		}
 	}
@@ -174,7 +178,7 @@ func (s *Serializer) SerializeArray(v interface{}) error {
 	}
 
 	for i := 0; i < val.Len(); i++ {
-		if err := s.SerializeValue(val.Index(i).Interface()); err != nil {
+		if err := s.serializeReflect(val.Index(i)); err != nil {
 			return err
 		}
 	}
@@ -193,17 +197,33 @@ func (s *Serializer) SerializeMap(v interface{}) error {
 
 	keys := val.MapKeys()
 	for _, key := range keys {
-		if err := s.SerializeValue(key.Interface()); err != nil {
+		if err := s.serializeReflect(key); err != nil {
 			return err
 		}
 		value := val.MapIndex(key)
-		if err := s.SerializeValue(value.Interface()); err != nil {
+		if err := s.serializeReflect(value); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// checkExported rejects struct types that have fields but none exported,
+// such as time.Time or a struct whose fields were all left lowercase by
+// mistake: they would encode as nothing and decode without consuming input.
+// Structs with no fields at all, like Unit, encode as nothing on purpose.
+func checkExported(t reflect.Type) error {
+	if t.NumField() == 0 {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("struct %v has no exported fields to encode", t)
+}
+
 func (s *Serializer) SerializeStruct(v interface{}) error {
 	val := reflect.ValueOf(v)
 	if val.Kind() == reflect.Ptr {
@@ -214,12 +234,15 @@ func (s *Serializer) SerializeStruct(v interface{}) error {
 	}
 
 	typ := val.Type()
+	if err := checkExported(typ); err != nil {
+		return err
+	}
 	for i := 0; i < val.NumField(); i++ {
 		field := typ.Field(i)
 		if field.PkgPath != "" {
 			continue
 		}
-		if err := s.SerializeValue(val.Field(i).Interface()); err != nil {
+		if err := s.serializeField(field, val.Field(i)); err != nil {
 			return err
 		}
 	}
@@ -242,6 +265,24 @@ func (s *Serializer) SerializeValue(v interface{}) error {
 	}
 
 	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr && val.IsNil() {
+		return s.SerializeOption(nil)
+	}
+	if m, ok := v.(Marshaler); ok {
+		return m.MarshalPostcard(s)
+	}
+	switch t := v.(type) {
+	case time.Duration:
+		return s.SerializeDuration(t)
+	case time.Time:
+		return s.serializeUnix(t, time.Second)
+	case netip.Addr:
+		return s.SerializeAddr(t)
+	case netip.AddrPort:
+		return s.SerializeAddrPort(t)
+	case netip.Prefix:
+		return s.SerializePrefix(t)
+	}
 
 	switch val.Kind() {
 	case reflect.Bool:
@@ -279,6 +320,10 @@ func (s *Serializer) SerializeValue(v interface{}) error {
 		return s.SerializeFloat32(float32(val.Float()))
 	case reflect.Float64:
 		return s.SerializeFloat64(val.Float())
+	case reflect.Complex64:
+		return s.SerializeComplex64(complex64(val.Complex()))
+	case reflect.Complex128:
+		return s.SerializeComplex128(val.Complex())
 	case reflect.String:
 		return s.SerializeString(val.String())
 	case reflect.Slice:
@@ -296,7 +341,7 @@ func (s *Serializer) SerializeValue(v interface{}) error {
 		if val.IsNil() {
 			return s.SerializeOption(nil)
 		}
-		return s.SerializeValue(val.Elem().Interface())
+		return s.serializeReflect(val.Elem())
 	default:
 		return fmt.Errorf("unsupported type: %v", val.Kind())
 	}
@@ -335,7 +380,9 @@ func SerializeBytes(v []byte) ([]byte, error) {
 }
 
 func SerializeRune(r rune) ([]byte, error) {
-	buf := make([]byte, 4)
-	n := utf8.EncodeRune(buf, r)
-	return SerializeBytes(buf[:n])
+	s := NewSerializer(nil)
+	if err := s.SerializeChar(r); err != nil {
+		return nil, err
+	}
+	return s.Result()
 }
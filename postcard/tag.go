@@ -0,0 +1,116 @@
+package postcard
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/yixinin/postcard-go/internal/fieldtag"
+)
+
+// parseFieldTag returns the options of the `postcard:"..."` tag of f.
+func parseFieldTag(f reflect.StructField) (fieldtag.Options, error) {
+	o, err := fieldtag.Parse(f.Tag)
+	if err != nil {
+		return o, fmt.Errorf("field %s: %w", f.Name, err)
+	}
+	return o, nil
+}
+
+// serializeField encodes the struct field f, honouring its tag.
+func (s *Serializer) serializeField(f reflect.StructField, val reflect.Value) error {
+	tag, err := parseFieldTag(f)
+	if err != nil {
+		return err
+	}
+	switch {
+	case tag.Fixint != "":
+		err = s.serializeFixint(val, tag.Fixint == "be")
+	case tag.Char:
+		if val.Kind() != reflect.Int32 {
+			err = fmt.Errorf("char needs a rune, got %v", val.Type())
+			break
+		}
+		err = s.SerializeChar(rune(val.Int()))
+	case tag.Unix != "":
+		if val.Type() != timeType {
+			err = fmt.Errorf("unix needs a time.Time, got %v", val.Type())
+			break
+		}
+		err = s.serializeUnix(val.Interface().(time.Time), unixUnits[tag.Unix])
+	case tag.Bytes && !isByteSlice(val.Type()):
+		if !isByteArray(val.Type()) {
+			err = fmt.Errorf("bytes needs a byte array, got %v", val.Type())
+			break
+		}
+		b := make([]byte, val.Len())
+		reflect.Copy(reflect.ValueOf(b), val)
+		err = s.SerializeBytes(b)
+	case tag.Max > 0:
+		err = s.serializeBounded(val, tag.Max)
+	default:
+		return s.serializeReflect(val)
+	}
+	if err != nil {
+		return fmt.Errorf("field %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// deserializeField decodes into the struct field f, honouring its tag.
+func (d *Deserializer) deserializeField(f reflect.StructField, val reflect.Value) error {
+	tag, err := parseFieldTag(f)
+	if err != nil {
+		return err
+	}
+	switch {
+	case tag.Fixint != "":
+		err = d.deserializeFixint(val, tag.Fixint == "be")
+	case tag.Char:
+		if val.Kind() != reflect.Int32 {
+			err = fmt.Errorf("char needs a rune, got %v", val.Type())
+			break
+		}
+		var r rune
+		if r, err = d.DeserializeChar(); err == nil {
+			val.SetInt(int64(r))
+		}
+	case tag.Unix != "":
+		if val.Type() != timeType {
+			err = fmt.Errorf("unix needs a time.Time, got %v", val.Type())
+			break
+		}
+		var t time.Time
+		if t, err = d.deserializeUnix(unixUnits[tag.Unix]); err == nil {
+			val.Set(reflect.ValueOf(t))
+		}
+	case tag.Bytes && !isByteSlice(val.Type()):
+		if !isByteArray(val.Type()) {
+			err = fmt.Errorf("bytes needs a byte array, got %v", val.Type())
+			break
+		}
+		b := make([]byte, val.Len())
+		if err = d.DeserializeByteArray(b); err == nil {
+			reflect.Copy(val, reflect.ValueOf(b))
+		}
+	case tag.Max > 0:
+		err = d.deserializeBounded(val, tag.Max)
+	default:
+		if !val.CanAddr() {
+			return nil
+		}
+		return d.DeserializeValue(val.Addr().Interface())
+	}
+	if err != nil {
+		return fmt.Errorf("field %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+func isByteArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+func isByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
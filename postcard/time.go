@@ -0,0 +1,213 @@
+package postcard
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// Duration is a time.Duration encoded like Rust's core::time::Duration: a
+// struct of whole seconds (u64) and nanoseconds (u32). SerializeValue and
+// DeserializeValue use this encoding for time.Duration values too; negative
+// durations have no Rust counterpart and fail to encode.
+type Duration time.Duration
+
+// Unix timestamps, the usual way to send a time.Time to a device: an i64
+// count of seconds, milliseconds, microseconds or nanoseconds since the Unix
+// epoch, as with chrono's ts_seconds family or embassy-time's Instant ticks.
+// A time.Time encodes as UnixSeconds unless its struct field is tagged
+// `postcard:"unix=ms"`, `postcard:"unix=us"` or `postcard:"unix=ns"`.
+// Decoded times are in UTC.
+type (
+	UnixSeconds time.Time
+	UnixMillis  time.Time
+	UnixMicros  time.Time
+	UnixNanos   time.Time
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+var unixUnits = map[string]time.Duration{
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"us": time.Microsecond,
+	"ns": time.Nanosecond,
+}
+
+func (s *Serializer) SerializeDuration(v time.Duration) error {
+	if v < 0 {
+		return fmt.Errorf("negative duration %v", v)
+	}
+	if err := s.SerializeUint64(uint64(v / time.Second)); err != nil {
+		return err
+	}
+	return s.SerializeUint32(uint32(v % time.Second))
+}
+
+// DeserializeDuration reads a Duration, carrying nanoseconds past a second
+// into the seconds as Rust does, and fails if the result does not fit a
+// time.Duration.
+func (d *Deserializer) DeserializeDuration() (time.Duration, error) {
+	secs, err := d.DeserializeUint64()
+	if err != nil {
+		return 0, err
+	}
+	nanos, err := d.DeserializeUint32()
+	if err != nil {
+		return 0, err
+	}
+	secs += uint64(nanos) / uint64(time.Second)
+	if secs > math.MaxInt64/uint64(time.Second) {
+		return 0, fmt.Errorf("duration of %d seconds overflows time.Duration", secs)
+	}
+	v := time.Duration(secs)*time.Second + time.Duration(nanos)%time.Second
+	if v < 0 {
+		return 0, fmt.Errorf("duration of %d seconds overflows time.Duration", secs)
+	}
+	return v, nil
+}
+
+func (s *Serializer) serializeUnix(t time.Time, unit time.Duration) error {
+	switch unit {
+	case time.Millisecond:
+		return s.SerializeInt64(t.UnixMilli())
+	case time.Microsecond:
+		return s.SerializeInt64(t.UnixMicro())
+	case time.Nanosecond:
+		return s.SerializeInt64(t.UnixNano())
+	default:
+		return s.SerializeInt64(t.Unix())
+	}
+}
+
+func (d *Deserializer) deserializeUnix(unit time.Duration) (time.Time, error) {
+	n, err := d.DeserializeInt64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch unit {
+	case time.Millisecond:
+		return time.UnixMilli(n).UTC(), nil
+	case time.Microsecond:
+		return time.UnixMicro(n).UTC(), nil
+	case time.Nanosecond:
+		return time.Unix(0, n).UTC(), nil
+	default:
+		return time.Unix(n, 0).UTC(), nil
+	}
+}
+
+func (v Duration) MarshalPostcard(s *Serializer) error {
+	return s.SerializeDuration(time.Duration(v))
+}
+
+func (v *Duration) UnmarshalPostcard(d *Deserializer) error {
+	decoded, err := d.DeserializeDuration()
+	if err != nil {
+		return err
+	}
+	*v = Duration(decoded)
+	return nil
+}
+
+func (v Duration) SizePostcard() int {
+	if v < 0 {
+		return 0
+	}
+	return SizeOfUint(uint64(time.Duration(v)/time.Second)) + SizeOfUint(uint64(time.Duration(v)%time.Second))
+}
+
+func (Duration) PostcardSchema() (*Schema, error) {
+	return &Schema{Name: "Duration", Kind: SchemaStruct, Fields: []SchemaField{
+		{Name: "secs", Type: &Schema{Name: "u64", Kind: SchemaU64}},
+		{Name: "nanos", Type: &Schema{Name: "u32", Kind: SchemaU32}},
+	}}, nil
+}
+
+func (v UnixSeconds) MarshalPostcard(s *Serializer) error {
+	return s.serializeUnix(time.Time(v), time.Second)
+}
+
+func (v *UnixSeconds) UnmarshalPostcard(d *Deserializer) error {
+	t, err := d.deserializeUnix(time.Second)
+	if err != nil {
+		return err
+	}
+	*v = UnixSeconds(t)
+	return nil
+}
+
+func (v UnixSeconds) SizePostcard() int {
+	return SizeOfInt(time.Time(v).Unix())
+}
+
+func (UnixSeconds) PostcardSchema() (*Schema, error) {
+	return &Schema{Name: "i64", Kind: SchemaI64}, nil
+}
+
+func (v UnixMillis) MarshalPostcard(s *Serializer) error {
+	return s.serializeUnix(time.Time(v), time.Millisecond)
+}
+
+func (v *UnixMillis) UnmarshalPostcard(d *Deserializer) error {
+	t, err := d.deserializeUnix(time.Millisecond)
+	if err != nil {
+		return err
+	}
+	*v = UnixMillis(t)
+	return nil
+}
+
+func (v UnixMillis) SizePostcard() int {
+	return SizeOfInt(time.Time(v).UnixMilli())
+}
+
+func (UnixMillis) PostcardSchema() (*Schema, error) {
+	return &Schema{Name: "i64", Kind: SchemaI64}, nil
+}
+
+func (v UnixMicros) MarshalPostcard(s *Serializer) error {
+	return s.serializeUnix(time.Time(v), time.Microsecond)
+}
+
+func (v *UnixMicros) UnmarshalPostcard(d *Deserializer) error {
+	t, err := d.deserializeUnix(time.Microsecond)
+	if err != nil {
+		return err
+	}
+	*v = UnixMicros(t)
+	return nil
+}
+
+func (v UnixMicros) SizePostcard() int {
+	return SizeOfInt(time.Time(v).UnixMicro())
+}
+
+func (UnixMicros) PostcardSchema() (*Schema, error) {
+	return &Schema{Name: "i64", Kind: SchemaI64}, nil
+}
+
+func (v UnixNanos) MarshalPostcard(s *Serializer) error {
+	return s.serializeUnix(time.Time(v), time.Nanosecond)
+}
+
+func (v *UnixNanos) UnmarshalPostcard(d *Deserializer) error {
+	t, err := d.deserializeUnix(time.Nanosecond)
+	if err != nil {
+		return err
+	}
+	*v = UnixNanos(t)
+	return nil
+}
+
+func (v UnixNanos) SizePostcard() int {
+	return SizeOfInt(time.Time(v).UnixNano())
+}
+
+func (UnixNanos) PostcardSchema() (*Schema, error) {
+	return &Schema{Name: "i64", Kind: SchemaI64}, nil
+}
@@ -0,0 +1,161 @@
+package postcard
+
+import "reflect"
+
+// Unit is Rust's unit type (). It encodes as nothing; use it for the unit
+// half of types such as Result<u8, ()>.
+type Unit struct{}
+
+// Tuple2 through Tuple8 model Rust tuples such as (u8, String). A tuple
+// encodes as its elements in order, with no length or tags; V0 is the
+// tuple's .0, V1 its .1 and so on.
+type Tuple2[A, B any] struct {
+	V0 A
+	V1 B
+}
+
+type Tuple3[A, B, C any] struct {
+	V0 A
+	V1 B
+	V2 C
+}
+
+type Tuple4[A, B, C, D any] struct {
+	V0 A
+	V1 B
+	V2 C
+	V3 D
+}
+
+type Tuple5[A, B, C, D, E any] struct {
+	V0 A
+	V1 B
+	V2 C
+	V3 D
+	V4 E
+}
+
+type Tuple6[A, B, C, D, E, F any] struct {
+	V0 A
+	V1 B
+	V2 C
+	V3 D
+	V4 E
+	V5 F
+}
+
+type Tuple7[A, B, C, D, E, F, G any] struct {
+	V0 A
+	V1 B
+	V2 C
+	V3 D
+	V4 E
+	V5 F
+	V6 G
+}
+
+type Tuple8[A, B, C, D, E, F, G, H any] struct {
+	V0 A
+	V1 B
+	V2 C
+	V3 D
+	V4 E
+	V5 F
+	V6 G
+	V7 H
+}
+
+func NewTuple2[A, B any](v0 A, v1 B) Tuple2[A, B] {
+	return Tuple2[A, B]{V0: v0, V1: v1}
+}
+
+// Get returns the elements of t.
+func (t Tuple2[A, B]) Get() (A, B) {
+	return t.V0, t.V1
+}
+
+func (Tuple2[A, B]) tupleElems() []reflect.Type {
+	return []reflect.Type{typeOf[A](), typeOf[B]()}
+}
+
+func NewTuple3[A, B, C any](v0 A, v1 B, v2 C) Tuple3[A, B, C] {
+	return Tuple3[A, B, C]{V0: v0, V1: v1, V2: v2}
+}
+
+// Get returns the elements of t.
+func (t Tuple3[A, B, C]) Get() (A, B, C) {
+	return t.V0, t.V1, t.V2
+}
+
+func (Tuple3[A, B, C]) tupleElems() []reflect.Type {
+	return []reflect.Type{typeOf[A](), typeOf[B](), typeOf[C]()}
+}
+
+func NewTuple4[A, B, C, D any](v0 A, v1 B, v2 C, v3 D) Tuple4[A, B, C, D] {
+	return Tuple4[A, B, C, D]{V0: v0, V1: v1, V2: v2, V3: v3}
+}
+
+// Get returns the elements of t.
+func (t Tuple4[A, B, C, D]) Get() (A, B, C, D) {
+	return t.V0, t.V1, t.V2, t.V3
+}
+
+func (Tuple4[A, B, C, D]) tupleElems() []reflect.Type {
+	return []reflect.Type{typeOf[A](), typeOf[B](), typeOf[C](), typeOf[D]()}
+}
+
+func NewTuple5[A, B, C, D, E any](v0 A, v1 B, v2 C, v3 D, v4 E) Tuple5[A, B, C, D, E] {
+	return Tuple5[A, B, C, D, E]{V0: v0, V1: v1, V2: v2, V3: v3, V4: v4}
+}
+
+// Get returns the elements of t.
+func (t Tuple5[A, B, C, D, E]) Get() (A, B, C, D, E) {
+	return t.V0, t.V1, t.V2, t.V3, t.V4
+}
+
+func (Tuple5[A, B, C, D, E]) tupleElems() []reflect.Type {
+	return []reflect.Type{typeOf[A](), typeOf[B](), typeOf[C](), typeOf[D](), typeOf[E]()}
+}
+
+func NewTuple6[A, B, C, D, E, F any](v0 A, v1 B, v2 C, v3 D, v4 E, v5 F) Tuple6[A, B, C, D, E, F] {
+	return Tuple6[A, B, C, D, E, F]{V0: v0, V1: v1, V2: v2, V3: v3, V4: v4, V5: v5}
+}
+
+// Get returns the elements of t.
+func (t Tuple6[A, B, C, D, E, F]) Get() (A, B, C, D, E, F) {
+	return t.V0, t.V1, t.V2, t.V3, t.V4, t.V5
+}
+
+func (Tuple6[A, B, C, D, E, F]) tupleElems() []reflect.Type {
+	return []reflect.Type{typeOf[A](), typeOf[B](), typeOf[C](), typeOf[D](), typeOf[E](), typeOf[F]()}
+}
+
+func NewTuple7[A, B, C, D, E, F, G any](v0 A, v1 B, v2 C, v3 D, v4 E, v5 F, v6 G) Tuple7[A, B, C, D, E, F, G] {
+	return Tuple7[A, B, C, D, E, F, G]{V0: v0, V1: v1, V2: v2, V3: v3, V4: v4, V5: v5, V6: v6}
+}
+
+// Get returns the elements of t.
+func (t Tuple7[A, B, C, D, E, F, G]) Get() (A, B, C, D, E, F, G) {
+	return t.V0, t.V1, t.V2, t.V3, t.V4, t.V5, t.V6
+}
+
+func (Tuple7[A, B, C, D, E, F, G]) tupleElems() []reflect.Type {
+	return []reflect.Type{typeOf[A](), typeOf[B](), typeOf[C](), typeOf[D](), typeOf[E](), typeOf[F](), typeOf[G]()}
+}
+
+func NewTuple8[A, B, C, D, E, F, G, H any](v0 A, v1 B, v2 C, v3 D, v4 E, v5 F, v6 G, v7 H) Tuple8[A, B, C, D, E, F, G, H] {
+	return Tuple8[A, B, C, D, E, F, G, H]{V0: v0, V1: v1, V2: v2, V3: v3, V4: v4, V5: v5, V6: v6, V7: v7}
+}
+
+// Get returns the elements of t.
+func (t Tuple8[A, B, C, D, E, F, G, H]) Get() (A, B, C, D, E, F, G, H) {
+	return t.V0, t.V1, t.V2, t.V3, t.V4, t.V5, t.V6, t.V7
+}
+
+func (Tuple8[A, B, C, D, E, F, G, H]) tupleElems() []reflect.Type {
+	return []reflect.Type{typeOf[A](), typeOf[B](), typeOf[C](), typeOf[D](), typeOf[E](), typeOf[F](), typeOf[G](), typeOf[H]()}
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -0,0 +1,56 @@
+package postcard
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// UUID is a uuid::Uuid, which binary formats encode as 16 length-prefixed
+// bytes. A plain [16]byte is a Rust [u8; 16] instead: 16 bytes with no
+// length. Byte arrays of other sizes can take the length-prefixed form with
+// the field tag `postcard:"bytes"`.
+type UUID [16]byte
+
+// ParseUUID parses the hyphenated form, such as
+// "67e55044-10b1-426f-9247-bb680e5fe0c8".
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+func (u UUID) MarshalPostcard(s *Serializer) error {
+	return s.SerializeBytes(u[:])
+}
+
+func (u *UUID) UnmarshalPostcard(d *Deserializer) error {
+	return d.DeserializeByteArray(u[:])
+}
+
+func (u UUID) SizePostcard() int {
+	return 1 + len(u)
+}
+
+func (UUID) PostcardSchema() (*Schema, error) {
+	return &Schema{Name: "Uuid", Kind: SchemaByteArray}, nil
+}
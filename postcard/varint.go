@@ -106,6 +106,21 @@ func encodeVarintUint64(n uint64) []byte {
 	return buf[:i+1]
 }
 
+// encodeVarintUint128 encodes the 128-bit value hi:lo.
+func encodeVarintUint128(hi, lo uint64) []byte {
+	maxLen := varintMax(16)
+	buf := make([]byte, maxLen)
+	i := 0
+	for hi != 0 || lo >= 128 {
+		buf[i] = byte(lo&0x7F) | 0x80
+		lo = lo>>7 | hi<<57
+		hi >>= 7
+		i++
+	}
+	buf[i] = byte(lo)
+	return buf[:i+1]
+}
+
 func encodeVarintUint(n uint) []byte {
 	if ^uint(0) == math.MaxUint64 {
 		return encodeVarintUint64(uint64(n))
@@ -179,6 +194,32 @@ func decodeVarintUint64(data []byte, pos *int) (uint64, error) {
 	return 0, ErrDeserializeBadVarint
 }
 
+func decodeVarintUint128(data []byte, pos *int) (hi, lo uint64, err error) {
+	maxLen := varintMax(16)
+	maxLast := maxOfLastByte(16)
+	for i := 0; i < maxLen; i++ {
+		if *pos >= len(data) {
+			return 0, 0, ErrDeserializeUnexpectedEnd
+		}
+		val := data[*pos]
+		*pos++
+		carry := uint64(val & 0x7F)
+		if shift := uint(7 * i); shift < 64 {
+			lo |= carry << shift
+			hi |= carry >> (64 - shift)
+		} else {
+			hi |= carry << (shift - 64)
+		}
+		if (val & 0x80) == 0 {
+			if i == maxLen-1 && val > maxLast {
+				return 0, 0, ErrDeserializeBadVarint
+			}
+			return hi, lo, nil
+		}
+	}
+	return 0, 0, ErrDeserializeBadVarint
+}
+
 func decodeVarintUint(data []byte, pos *int) (uint, error) {
 	if ^uint(0) == math.MaxUint64 {
 		val, err := decodeVarintUint64(data, pos)
@@ -262,3 +303,48 @@ func decodeFloat64LE(data []byte, pos *int) (float64, error) {
 	*pos += 8
 	return math.Float64frombits(val), nil
 }
+
+func encodeUint16BE(n uint16) []byte {
+	buf := make([]byte, 2)
+	binary.BigEndian.PutUint16(buf, n)
+	return buf
+}
+
+func encodeUint32BE(n uint32) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, n)
+	return buf
+}
+
+func encodeUint64BE(n uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, n)
+	return buf
+}
+
+func decodeUint16BE(data []byte, pos *int) (uint16, error) {
+	if *pos+2 > len(data) {
+		return 0, ErrDeserializeUnexpectedEnd
+	}
+	val := binary.BigEndian.Uint16(data[*pos : *pos+2])
+	*pos += 2
+	return val, nil
+}
+
+func decodeUint32BE(data []byte, pos *int) (uint32, error) {
+	if *pos+4 > len(data) {
+		return 0, ErrDeserializeUnexpectedEnd
+	}
+	val := binary.BigEndian.Uint32(data[*pos : *pos+4])
+	*pos += 4
+	return val, nil
+}
+
+func decodeUint64BE(data []byte, pos *int) (uint64, error) {
+	if *pos+8 > len(data) {
+		return 0, ErrDeserializeUnexpectedEnd
+	}
+	val := binary.BigEndian.Uint64(data[*pos : *pos+8])
+	*pos += 8
+	return val, nil
+}
@@ -31,3 +31,14 @@ func zigzagEncodeInt(n int) uint {
 func zigzagDecodeInt(n uint) int {
 	return int(zigzagDecodeInt64(uint64(n)))
 }
+
+// zigzagEncodeInt128 zigzag-encodes the two's complement value hi:lo.
+func zigzagEncodeInt128(hi, lo uint64) (uint64, uint64) {
+	sign := -(hi >> 63)
+	return (hi<<1 | lo>>63) ^ sign, (lo << 1) ^ sign
+}
+
+func zigzagDecodeInt128(hi, lo uint64) (uint64, uint64) {
+	sign := -(lo & 1)
+	return (hi >> 1) ^ sign, (lo>>1 | hi<<63) ^ sign
+}
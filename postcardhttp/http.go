@@ -0,0 +1,128 @@
+// Package postcardhttp carries postcard-encoded values over net/http using
+// the application/postcard media type.
+package postcardhttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/yixinin/postcard-go/postcard"
+	"github.com/yixinin/postcard-go/rpc"
+)
+
+// ContentType is the media type of a postcard-encoded body.
+const ContentType = "application/postcard"
+
+var (
+	// ErrContentType reports a body that is not application/postcard.
+	// Servers usually answer it with 415 Unsupported Media Type.
+	ErrContentType = errors.New("postcardhttp: content type is not " + ContentType)
+	// ErrTooLarge reports a body over the size limit. Servers usually
+	// answer it with 413 Request Entity Too Large.
+	ErrTooLarge = errors.New("postcardhttp: body too large")
+)
+
+// StatusError is returned by Do for a response outside the 2xx range.
+type StatusError struct {
+	StatusCode int
+	// Body holds the start of the response body, for diagnostics.
+	Body []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("postcardhttp: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Write encodes v and writes it as the response with the given status code.
+// Nothing is written if v cannot be encoded.
+func Write(w http.ResponseWriter, status int, v interface{}) error {
+	body, err := postcard.Serialize(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
+
+// DecodeRequest decodes the body of r into v. The body must be
+// application/postcard and at most maxBytes long. A maxBytes of zero applies
+// rpc.MaxFrameLen, the limit on messages received over rpc transports.
+func DecodeRequest(r *http.Request, v interface{}, maxBytes int64) error {
+	return decode(r.Header, r.Body, v, maxBytes)
+}
+
+// DecodeResponse decodes the body of resp into v under the same rules as
+// DecodeRequest. It does not close the body.
+func DecodeResponse(resp *http.Response, v interface{}, maxBytes int64) error {
+	return decode(resp.Header, resp.Body, v, maxBytes)
+}
+
+func decode(h http.Header, body io.Reader, v interface{}, maxBytes int64) error {
+	if mt, _, err := mime.ParseMediaType(h.Get("Content-Type")); err != nil || mt != ContentType {
+		return ErrContentType
+	}
+	if maxBytes == 0 {
+		maxBytes = rpc.MaxFrameLen
+	}
+	if n := h.Get("Content-Length"); n != "" {
+		if size, err := strconv.ParseInt(n, 10, 64); err == nil && size > maxBytes {
+			return ErrTooLarge
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxBytes+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > maxBytes {
+		return ErrTooLarge
+	}
+	return postcard.Deserialize(data, v)
+}
+
+// NewRequest returns a request with req encoded as its body and the
+// Content-Type and Accept headers set, ready for any http.RoundTripper.
+func NewRequest(ctx context.Context, method, url string, req interface{}) (*http.Request, error) {
+	body, err := postcard.Serialize(req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", ContentType)
+	r.Header.Set("Accept", ContentType)
+	return r, nil
+}
+
+// Do posts req to url with client, or http.DefaultClient if client is nil,
+// and decodes the response into resp. A response outside the 2xx range is
+// reported as a *StatusError.
+func Do(ctx context.Context, client *http.Client, url string, req, resp interface{}) error {
+	r, err := NewRequest(ctx, http.MethodPost, url, req)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return &StatusError{StatusCode: res.StatusCode, Body: body}
+	}
+	return DecodeResponse(res, resp, 0)
+}
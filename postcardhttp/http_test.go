@@ -0,0 +1,111 @@
+package postcardhttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yixinin/postcard-go/rpc"
+)
+
+type sumRequest struct {
+	Values []int32
+}
+
+type sumResponse struct {
+	Sum   int64
+	Count uint32
+}
+
+func sumHandler(w http.ResponseWriter, r *http.Request) {
+	var req sumRequest
+	switch err := DecodeRequest(r, &req, 64); {
+	case errors.Is(err, ErrContentType):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	case errors.Is(err, ErrTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := sumResponse{Count: uint32(len(req.Values))}
+	for _, v := range req.Values {
+		resp.Sum += int64(v)
+	}
+	Write(w, http.StatusOK, &resp)
+}
+
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(sumHandler))
+	defer srv.Close()
+	ctx := context.Background()
+
+	var resp sumResponse
+	if err := Do(ctx, srv.Client(), srv.URL, &sumRequest{Values: []int32{1, -2, 300}}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp != (sumResponse{Sum: 299, Count: 3}) {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	err := Do(ctx, srv.Client(), srv.URL, &sumRequest{Values: make([]int32, 100)}, &resp)
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized request error = %v", err)
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sumHandler(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}")))
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("JSON request status = %d", rec.Code)
+	}
+
+	// Without a Content-Length the limit applies while reading.
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(bytes.Repeat([]byte{0}, 65)))
+	r.ContentLength = -1
+	r.Header.Set("Content-Type", ContentType+"; charset=binary")
+	var req sumRequest
+	if err := DecodeRequest(r, &req, 64); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("chunked oversized request error = %v", err)
+	}
+
+	// A limit of zero is the rpc frame limit.
+	r = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(make([]byte, rpc.MaxFrameLen+1)))
+	r.Header.Set("Content-Type", ContentType)
+	if err := DecodeRequest(r, &req, 0); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("request over rpc.MaxFrameLen error = %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte{0x7F}))
+	r.Header.Set("Content-Type", ContentType)
+	sumHandler(rec, r)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("truncated request status = %d", rec.Code)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := Write(rec, http.StatusCreated, &sumResponse{Sum: -1, Count: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Type") != ContentType {
+		t.Errorf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if got := rec.Body.Bytes(); !bytes.Equal(got, []byte{0x01, 0x02}) {
+		t.Errorf("body = %x", got)
+	}
+	var resp sumResponse
+	if err := DecodeResponse(rec.Result(), &resp, 0); err != nil || resp != (sumResponse{Sum: -1, Count: 2}) {
+		t.Errorf("DecodeResponse = %+v, %v", resp, err)
+	}
+}
@@ -0,0 +1,162 @@
+// Package capture records the frames crossing an rpc.Transport to a file
+// and replays them later.
+//
+// A capture file is postcard-encoded: a header followed by one record per
+// frame until the end of the file.
+//
+//	header: magic [u8; 4] = "PCRC", version u8 = 1, start i64 (Unix nanoseconds)
+//	record: offset u64 (nanoseconds since start), direction u8, frame [u8]
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+// Direction tells which way a frame crossed the recorded transport.
+type Direction uint8
+
+const (
+	// Sent frames were sent on the transport.
+	Sent Direction = iota
+	// Received frames were received from it.
+	Received
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Sent:
+		return "sent"
+	case Received:
+		return "received"
+	}
+	return fmt.Sprintf("Direction(%d)", uint8(d))
+}
+
+// Record is one captured frame.
+type Record struct {
+	// Offset is the time since the capture started.
+	Offset time.Duration
+	Dir    Direction
+	Frame  []byte
+}
+
+const version = 1
+
+var magic = [4]byte{'P', 'C', 'R', 'C'}
+
+var ErrBadCapture = errors.New("capture: not a capture file")
+
+type fileHeader struct {
+	Magic   [4]byte
+	Version uint8
+	Start   int64
+}
+
+type wireRecord struct {
+	Offset uint64
+	Dir    Direction
+	Frame  []byte
+}
+
+// Writer appends records to a capture file. It is safe for concurrent use.
+type Writer struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	err   error
+}
+
+// NewWriter writes a capture header to w and starts the clock.
+func NewWriter(w io.Writer) (*Writer, error) {
+	start := time.Now()
+	b, err := postcard.Serialize(fileHeader{Magic: magic, Version: version, Start: start.UnixNano()})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, start: start}, nil
+}
+
+// Write records frame as crossing in direction dir now.
+func (w *Writer) Write(dir Direction, frame []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	rec := wireRecord{Offset: uint64(time.Since(w.start)), Dir: dir, Frame: frame}
+	b, err := postcard.Serialize(rec)
+	if err == nil {
+		_, err = w.w.Write(b)
+	}
+	w.err = err
+	return err
+}
+
+// Err returns the first error writing failed with.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Reader decodes a capture file.
+type Reader struct {
+	// Start is when the capture began.
+	Start time.Time
+	d     *postcard.Deserializer
+}
+
+// NewReader reads the whole capture from r and decodes its header.
+func NewReader(r io.Reader) (*Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	d := postcard.NewDeserializer(data)
+	var h fileHeader
+	if err := d.DeserializeValue(&h); err != nil || h.Magic != magic {
+		return nil, ErrBadCapture
+	}
+	if h.Version != version {
+		return nil, fmt.Errorf("capture: unsupported version %d", h.Version)
+	}
+	return &Reader{Start: time.Unix(0, h.Start), d: d}, nil
+}
+
+// Next returns the next record, or io.EOF after the last. A file cut short
+// while recording ends with an error wrapping
+// postcard.ErrDeserializeUnexpectedEnd.
+func (r *Reader) Next() (Record, error) {
+	if len(r.d.Remaining()) == 0 {
+		return Record{}, io.EOF
+	}
+	var rec wireRecord
+	if err := r.d.DeserializeValue(&rec); err != nil {
+		return Record{}, fmt.Errorf("capture: %w", err)
+	}
+	return Record{Offset: time.Duration(rec.Offset), Dir: rec.Dir, Frame: rec.Frame}, nil
+}
+
+// ReadAll returns the remaining records.
+func (r *Reader) ReadAll() ([]Record, error) {
+	var recs []Record
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return recs, nil
+		}
+		if err != nil {
+			return recs, err
+		}
+		recs = append(recs, rec)
+	}
+}
@@ -0,0 +1,164 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/yixinin/postcard-go/postcard"
+	"github.com/yixinin/postcard-go/rpc"
+)
+
+var doubleEndpoint = rpc.MustEndpoint[int32, int32]("capture/double")
+
+// recordSession runs calls against a live dispatcher, recording the client
+// side, and returns the capture file.
+func recordSession(t *testing.T, args ...int32) []byte {
+	t.Helper()
+	d := rpc.NewDispatcher()
+	err := rpc.Handle(d, doubleEndpoint, func(ctx context.Context, n int32) (int32, error) {
+		return 2 * n, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, device := rpc.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Serve(ctx, device)
+		close(done)
+	}()
+
+	var file bytes.Buffer
+	w, err := NewWriter(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := rpc.NewClient(NewRecorder(host, w))
+	for _, n := range args {
+		if _, err := rpc.Call(context.Background(), c, doubleEndpoint, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Close()
+	cancel()
+	<-done
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return file.Bytes()
+}
+
+func TestRecord(t *testing.T) {
+	before := time.Now()
+	file := recordSession(t, 1, 2, 3)
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Start.Before(before.Add(-time.Second)) || r.Start.After(time.Now()) {
+		t.Errorf("Start = %v", r.Start)
+	}
+	recs, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 6 {
+		t.Fatalf("got %d records, want 6", len(recs))
+	}
+	var last time.Duration
+	for i, rec := range recs {
+		wantDir := Sent
+		if i%2 == 1 {
+			wantDir = Received
+		}
+		if rec.Dir != wantDir {
+			t.Errorf("record %d is %v, want %v", i, rec.Dir, wantDir)
+		}
+		if rec.Offset < last {
+			t.Errorf("record %d goes back in time", i)
+		}
+		last = rec.Offset
+		hdr, body, err := rpc.DecodeFrame(rec.Frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Dir == Received {
+			var n int32
+			postcard.Deserialize(body, &n)
+			if hdr.Key != doubleEndpoint.RespKey || n != int32(i+1) {
+				t.Errorf("record %d: reply %d with key %v", i, n, hdr.Key)
+			}
+		}
+	}
+
+	// A file cut short keeps the records before the cut.
+	r, _ = NewReader(bytes.NewReader(file[:len(file)-1]))
+	recs, err = r.ReadAll()
+	if len(recs) != 5 || !errors.Is(err, postcard.ErrDeserializeUnexpectedEnd) {
+		t.Errorf("truncated capture: %d records, %v", len(recs), err)
+	}
+	if _, err := NewReader(bytes.NewReader([]byte("nope"))); !errors.Is(err, ErrBadCapture) {
+		t.Errorf("NewReader(garbage) error = %v", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	file := recordSession(t, 10, 20)
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same calls get the recorded replies without a device.
+	c := rpc.NewClient(NewReplayer(recs, ReplayOptions{Strict: true}))
+	defer c.Close()
+	for _, n := range []int32{10, 20} {
+		got, err := rpc.Call(context.Background(), c, doubleEndpoint, n)
+		if err != nil || got != 2*n {
+			t.Errorf("Call(%d) = %d, %v", n, got, err)
+		}
+	}
+
+	// A strict replay rejects a request that differs from the capture.
+	rp := NewReplayer(recs, ReplayOptions{Strict: true})
+	frame, _ := rpc.EncodeFrame(rpc.WireHeader{Key: doubleEndpoint.ReqKey, SeqNo: 1}, int32(11))
+	if err := rp.Send(frame); !errors.Is(err, ErrDiverged) {
+		t.Errorf("Send(other request) error = %v", err)
+	}
+	rp.Close()
+	if _, err := rp.Recv(); err != io.EOF {
+		t.Errorf("Recv after Close = %v", err)
+	}
+}
+
+func TestReplayPacing(t *testing.T) {
+	recs := []Record{
+		{Offset: 0, Dir: Received, Frame: []byte{1}},
+		{Offset: 40 * time.Millisecond, Dir: Received, Frame: []byte{2}},
+	}
+	rp := NewReplayer(recs, ReplayOptions{Pace: true})
+	// Time spent before the first Recv does not count against the pace.
+	time.Sleep(50 * time.Millisecond)
+	begin := time.Now()
+	for _, want := range []byte{1, 2} {
+		frame, err := rp.Recv()
+		if err != nil || len(frame) != 1 || frame[0] != want {
+			t.Fatalf("Recv = %x, %v", frame, err)
+		}
+	}
+	if elapsed := time.Since(begin); elapsed < 40*time.Millisecond {
+		t.Errorf("paced replay took %v", elapsed)
+	}
+	if _, err := rp.Recv(); err != io.EOF {
+		t.Errorf("Recv at end = %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/yixinin/postcard-go/rpc"
+)
+
+// recorder copies the frames of a transport to a Writer.
+type recorder struct {
+	rpc.Transport
+	w *Writer
+}
+
+// NewRecorder wraps t so every frame it sends or receives is written to w.
+// Recording failures do not affect the transport; check w.Err.
+func NewRecorder(t rpc.Transport, w *Writer) rpc.Transport {
+	return &recorder{Transport: t, w: w}
+}
+
+// Send records the frame first, so a reply received before the underlying
+// Send returns is still recorded after its request.
+func (r *recorder) Send(frame []byte) error {
+	r.w.Write(Sent, frame)
+	return r.Transport.Send(frame)
+}
+
+func (r *recorder) Recv() ([]byte, error) {
+	frame, err := r.Transport.Recv()
+	if err != nil {
+		return nil, err
+	}
+	r.w.Write(Received, frame)
+	return frame, nil
+}
+
+var ErrDiverged = errors.New("capture: replay diverged from the capture")
+
+// ReplayOptions tune a Replayer.
+type ReplayOptions struct {
+	// Pace releases each received frame no earlier than its recorded
+	// offset from the first record, counting from the first call to Recv.
+	Pace bool
+	// Strict makes Send fail with ErrDiverged when a frame differs from the
+	// one recorded at that point.
+	Strict bool
+}
+
+// Replayer is a transport that plays a capture back from the recorded side:
+// Recv returns the Received frames in order and Send stands in for the Sent
+// ones. A received frame is only released once every frame sent before it
+// in the capture has been sent again, so a client issuing the same requests
+// gets the same replies. Recv returns io.EOF after the last record.
+type Replayer struct {
+	records []Record
+	opts    ReplayOptions
+	// sends holds the indexes of the Sent records.
+	sends []int
+
+	mu      sync.Mutex
+	sent    int
+	changed chan struct{}
+	closed  bool
+	// next is the first record Recv has not passed, and sentBefore the
+	// number of Sent records ahead of it.
+	next       int
+	sentBefore int
+	// start is when Recv was first called, which pacing counts from.
+	start time.Time
+}
+
+func NewReplayer(records []Record, opts ReplayOptions) *Replayer {
+	var sends []int
+	for i, rec := range records {
+		if rec.Dir == Sent {
+			sends = append(sends, i)
+		}
+	}
+	return &Replayer{records: records, opts: opts, sends: sends, changed: make(chan struct{})}
+}
+
+// broadcast wakes the calls waiting for a change. mu must be held.
+func (r *Replayer) broadcast() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+func (r *Replayer) Send(frame []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return io.ErrClosedPipe
+	}
+	n := r.sent
+	r.sent++
+	r.broadcast()
+	if !r.opts.Strict {
+		return nil
+	}
+	if n >= len(r.sends) {
+		return fmt.Errorf("%w: sent frame %d was not recorded", ErrDiverged, n)
+	}
+	if rec := r.records[r.sends[n]]; !bytes.Equal(rec.Frame, frame) {
+		return fmt.Errorf("%w: sent frame %d is %x, recorded %x", ErrDiverged, n, frame, rec.Frame)
+	}
+	return nil
+}
+
+func (r *Replayer) Recv() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.start.IsZero() {
+		r.start = time.Now()
+	}
+	for {
+		if r.closed {
+			return nil, io.EOF
+		}
+		// Skip to the next received frame, counting the sends it waits for.
+		for r.next < len(r.records) && r.records[r.next].Dir == Sent {
+			r.next++
+			r.sentBefore++
+		}
+		if r.next == len(r.records) {
+			return nil, io.EOF
+		}
+		rec := r.records[r.next]
+
+		var wait time.Duration
+		if r.opts.Pace {
+			wait = time.Until(r.start.Add(rec.Offset - r.records[0].Offset))
+		}
+		ready := r.sent >= r.sentBefore
+		if ready && wait <= 0 {
+			r.next++
+			return rec.Frame, nil
+		}
+
+		changed := r.changed
+		r.mu.Unlock()
+		if ready {
+			select {
+			case <-changed:
+			case <-time.After(wait):
+			}
+		} else {
+			<-changed
+		}
+		r.mu.Lock()
+	}
+}
+
+func (r *Replayer) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		r.broadcast()
+	}
+	return nil
+}
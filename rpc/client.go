@@ -0,0 +1,184 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+var ErrClosed = errors.New("rpc: client closed")
+
+// call is a request waiting for the reply with its sequence number and
+// either its response key or ErrorKey.
+type call struct {
+	respKey Key
+	reply   chan reply
+}
+
+type reply struct {
+	key  Key
+	body []byte
+}
+
+// Client issues requests over a single transport. Any number of calls may
+// be in flight at once; replies are matched to callers by sequence number.
+type Client struct {
+	conn Transport
+
+	mu      sync.Mutex
+	seq     uint32
+	pending map[uint32]*call
+	err     error
+
+	subMu    sync.RWMutex
+	subs     map[Key]map[subscriber]struct{}
+	topicSeq atomic.Uint32
+}
+
+// NewClient starts receiving replies from conn. Close the client to release
+// it.
+func NewClient(conn Transport) *Client {
+	c := &Client{
+		conn:    conn,
+		pending: map[uint32]*call{},
+		subs:    map[Key]map[subscriber]struct{}{},
+	}
+	go c.readLoop()
+	return c
+}
+
+// Close closes the transport, fails the calls in flight with ErrClosed and
+// closes every subscription.
+func (c *Client) Close() error {
+	c.fail(ErrClosed)
+	return c.conn.Close()
+}
+
+// Call sends req to ep and waits for the response, the end of ctx or the
+// failure of the connection. If the device replies with a WireError, the
+// returned error wraps it.
+func Call[Req, Resp any](ctx context.Context, c *Client, ep Endpoint[Req, Resp], req Req) (Resp, error) {
+	var resp Resp
+	seq, ch, err := c.register(ep.RespKey)
+	if err != nil {
+		return resp, err
+	}
+	defer c.unregister(seq)
+
+	frame, err := EncodeFrame(WireHeader{Key: ep.ReqKey, SeqNo: seq}, req)
+	if err != nil {
+		return resp, err
+	}
+	if err := c.send(frame); err != nil {
+		return resp, err
+	}
+
+	select {
+	case r, ok := <-ch:
+		if !ok {
+			return resp, c.closeErr()
+		}
+		if r.key == ErrorKey {
+			var werr WireError
+			if err := postcard.Deserialize(r.body, &werr); err != nil {
+				return resp, fmt.Errorf("rpc: %s: undecodable error reply: %w", ep.Path, err)
+			}
+			return resp, fmt.Errorf("rpc: %s: %w", ep.Path, werr)
+		}
+		if err := postcard.Deserialize(r.body, &resp); err != nil {
+			return resp, fmt.Errorf("rpc: %s: %w", ep.Path, err)
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return resp, ctx.Err()
+	}
+}
+
+// register allocates a sequence number no call in flight is using.
+func (c *Client) register(respKey Key) (uint32, chan reply, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, nil, c.err
+	}
+	for {
+		c.seq++
+		if _, busy := c.pending[c.seq]; !busy {
+			break
+		}
+	}
+	ch := make(chan reply, 1)
+	c.pending[c.seq] = &call{respKey: respKey, reply: ch}
+	return c.seq, ch, nil
+}
+
+func (c *Client) unregister(seq uint32) {
+	c.mu.Lock()
+	delete(c.pending, seq)
+	c.mu.Unlock()
+}
+
+func (c *Client) send(frame []byte) error {
+	if err := c.conn.Send(frame); err != nil {
+		c.fail(fmt.Errorf("rpc: send failed: %w", err))
+		return c.closeErr()
+	}
+	return nil
+}
+
+func (c *Client) closeErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// fail records err as the reason the client stopped, wakes every call in
+// flight and closes the subscriptions. Only the first reason is kept.
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.err = err
+	for seq, cl := range c.pending {
+		close(cl.reply)
+		delete(c.pending, seq)
+	}
+	c.mu.Unlock()
+	c.closeSubs()
+}
+
+func (c *Client) readLoop() {
+	for {
+		frame, err := c.conn.Recv()
+		if err != nil {
+			c.fail(fmt.Errorf("rpc: connection lost: %w", err))
+			return
+		}
+		hdr, body, err := DecodeFrame(frame)
+		if err != nil {
+			continue
+		}
+		if c.dispatchTopic(hdr, body) {
+			continue
+		}
+		// Frames matching no call, such as replies to calls that gave up or
+		// topics nobody subscribed to, are dropped.
+		c.mu.Lock()
+		cl := c.pending[hdr.SeqNo]
+		if cl != nil && (hdr.Key == cl.respKey || hdr.Key == ErrorKey) {
+			delete(c.pending, hdr.SeqNo)
+		} else {
+			cl = nil
+		}
+		c.mu.Unlock()
+		if cl != nil {
+			cl.reply <- reply{key: hdr.Key, body: body}
+		}
+	}
+}
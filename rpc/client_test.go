@@ -0,0 +1,155 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	echoEndpoint  = MustEndpoint[uint32, uint32]("test/echo")
+	blockEndpoint = MustEndpoint[uint8, uint8]("test/block")
+)
+
+// startPipe serves d over one end of a net.Pipe and returns a client on the
+// other end along with the server's connection.
+func startPipe(t *testing.T, d *Dispatcher) (*Client, net.Conn) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	c := serveTransports(t, d, NewStreamTransport(clientConn), NewStreamTransport(serverConn))
+	return c, serverConn
+}
+
+// serveTransports serves d on server and returns a client on client.
+func serveTransports(t *testing.T, d *Dispatcher, client, server Transport) *Client {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Serve(ctx, server)
+		close(done)
+	}()
+	c := NewClient(client)
+	t.Cleanup(func() {
+		c.Close()
+		cancel()
+		<-done
+	})
+	return c
+}
+
+func newClientTestDispatcher(t *testing.T, release <-chan struct{}) *Dispatcher {
+	d := NewDispatcher()
+	err := Handle(d, echoEndpoint, func(ctx context.Context, n uint32) (uint32, error) {
+		// Later requests finish first, so replies arrive out of order.
+		time.Sleep(time.Duration(50-n%50) * 100 * time.Microsecond)
+		return n, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Handle(d, blockEndpoint, func(ctx context.Context, n uint8) (uint8, error) {
+		select {
+		case <-release:
+			return n, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestClientConcurrentCalls(t *testing.T) {
+	c, _ := startPipe(t, newClientTestDispatcher(t, nil))
+	var wg sync.WaitGroup
+	for i := uint32(0); i < 100; i++ {
+		wg.Add(1)
+		go func(n uint32) {
+			defer wg.Done()
+			got, err := Call(context.Background(), c, echoEndpoint, n)
+			if err != nil || got != n {
+				t.Errorf("Call(%d) = %d, %v", n, got, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestClientDeadline(t *testing.T) {
+	release := make(chan struct{})
+	c, _ := startPipe(t, newClientTestDispatcher(t, release))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := Call(ctx, c, blockEndpoint, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Call error = %v, want deadline exceeded", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := Call(ctx, c, blockEndpoint, 2); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Call error = %v, want canceled", err)
+	}
+
+	// The late replies to the abandoned calls must not confuse later ones.
+	close(release)
+	for i := uint32(0); i < 3; i++ {
+		if got, err := Call(context.Background(), c, echoEndpoint, i); err != nil || got != i {
+			t.Errorf("Call(%d) = %d, %v", i, got, err)
+		}
+	}
+}
+
+func TestClientTransportFailure(t *testing.T) {
+	c, serverConn := startPipe(t, newClientTestDispatcher(t, make(chan struct{})))
+
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		go func(n uint8) {
+			_, err := Call(context.Background(), c, blockEndpoint, n)
+			errs <- err
+		}(uint8(i))
+	}
+	// Let the requests reach the server before the connection dies.
+	time.Sleep(20 * time.Millisecond)
+	serverConn.Close()
+	for i := 0; i < cap(errs); i++ {
+		select {
+		case err := <-errs:
+			if err == nil || errors.Is(err, ErrClosed) {
+				t.Errorf("in-flight call error = %v, want connection lost", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("in-flight call did not fail")
+		}
+	}
+	if _, err := Call(context.Background(), c, echoEndpoint, 1); err == nil {
+		t.Error("call on a dead connection succeeded")
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	c, _ := startPipe(t, newClientTestDispatcher(t, make(chan struct{})))
+	errs := make(chan error, 1)
+	go func() {
+		_, err := Call(context.Background(), c, blockEndpoint, 1)
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+	if err := <-errs; !errors.Is(err, ErrClosed) {
+		t.Errorf("in-flight call error = %v, want ErrClosed", err)
+	}
+	if _, err := Call(context.Background(), c, echoEndpoint, 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("call after Close error = %v, want ErrClosed", err)
+	}
+}
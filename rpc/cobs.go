@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var errBadCOBS = errors.New("rpc: malformed COBS frame")
+
+// cobsEncode appends the COBS encoding of src to dst, without the trailing
+// zero delimiter.
+func cobsEncode(dst, src []byte) []byte {
+	codeIdx := len(dst)
+	dst = append(dst, 0)
+	code := byte(1)
+	for i, b := range src {
+		if b == 0 {
+			dst[codeIdx] = code
+			codeIdx = len(dst)
+			dst = append(dst, 0)
+			code = 1
+			continue
+		}
+		dst = append(dst, b)
+		code++
+		// A full block only needs a new code byte if data follows.
+		if code == 0xFF && i < len(src)-1 {
+			dst[codeIdx] = code
+			codeIdx = len(dst)
+			dst = append(dst, 0)
+			code = 1
+		}
+	}
+	dst[codeIdx] = code
+	return dst
+}
+
+// cobsDecode reverses cobsEncode. src must not include the delimiter.
+func cobsDecode(src []byte) ([]byte, error) {
+	dst := make([]byte, 0, len(src))
+	for i := 0; i < len(src); {
+		code := int(src[i])
+		if code == 0 || i+code > len(src) {
+			return nil, errBadCOBS
+		}
+		for _, b := range src[i+1 : i+code] {
+			if b == 0 {
+				return nil, errBadCOBS
+			}
+		}
+		dst = append(dst, src[i+1:i+code]...)
+		i += code
+		if code < 0xFF && i < len(src) {
+			dst = append(dst, 0)
+		}
+	}
+	return dst, nil
+}
+
+// cobsTransport delimits COBS-encoded frames with zero bytes.
+type cobsTransport struct {
+	rwc io.ReadWriteCloser
+	r   *bufio.Reader
+	wmu sync.Mutex
+}
+
+// NewCOBSTransport carries frames over a byte stream as COBS-encoded,
+// zero-delimited packets, the framing postcard-rpc uses on serial links.
+// Since every zero byte starts a new frame, the receiver resynchronizes
+// after line noise: malformed and oversized frames are skipped rather than
+// ending the transport.
+func NewCOBSTransport(rwc io.ReadWriteCloser) Transport {
+	return &cobsTransport{rwc: rwc, r: bufio.NewReader(rwc)}
+}
+
+// OpenSerial opens a serial device such as /dev/ttyUSB0 or a pseudo-terminal
+// with COBS framing. The line must already be configured for raw 8-bit
+// transfer at the right baud rate, for example with stty.
+func OpenSerial(name string) (Transport, error) {
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return NewCOBSTransport(f), nil
+}
+
+func (t *cobsTransport) Send(frame []byte) error {
+	if len(frame) > MaxFrameLen {
+		return fmt.Errorf("%w: %d bytes", ErrFrameTooLong, len(frame))
+	}
+	buf := make([]byte, 0, len(frame)+len(frame)/254+2)
+	buf = cobsEncode(buf, frame)
+	buf = append(buf, 0)
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	_, err := t.rwc.Write(buf)
+	return err
+}
+
+func (t *cobsTransport) Recv() ([]byte, error) {
+	maxEncoded := MaxFrameLen + MaxFrameLen/254 + 1
+	var packet []byte
+	overflow := false
+	for {
+		chunk, err := t.r.ReadSlice(0)
+		if err != nil && err != bufio.ErrBufferFull {
+			if err == io.EOF && len(packet)+len(chunk) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if !overflow {
+			packet = append(packet, chunk...)
+			if len(packet) > maxEncoded+1 {
+				overflow = true
+				packet = nil
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		if !overflow && len(packet) > 1 {
+			if frame, err := cobsDecode(packet[:len(packet)-1]); err == nil {
+				return frame, nil
+			}
+		}
+		packet = packet[:0]
+		overflow = false
+	}
+}
+
+func (t *cobsTransport) Close() error {
+	return t.rwc.Close()
+}
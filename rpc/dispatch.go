@@ -0,0 +1,192 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+var ErrUnknownKey = errors.New("rpc: no handler for key")
+
+// Request is an incoming frame routed to a Handler.
+type Request struct {
+	Path   string
+	Header WireHeader
+	Body   []byte
+}
+
+// Handler processes a request and returns the encoded response message.
+type Handler func(ctx context.Context, req *Request) ([]byte, error)
+
+type route struct {
+	path    string
+	respKey Key
+	handler Handler
+	// topic routes take messages that get no reply.
+	topic bool
+	// reqSchema and respSchema are listed by the standard endpoints. Topic
+	// routes keep their message schema in reqSchema.
+	reqSchema, respSchema *postcard.Schema
+}
+
+// Dispatcher routes request frames to the handlers registered for their key.
+// It is safe for concurrent use.
+type Dispatcher struct {
+	mu     sync.RWMutex
+	routes map[Key]*route
+	conns  map[Transport]struct{}
+	seq    atomic.Uint32
+	// topicsOut are the topics declared with DeclareTopic. Their routes
+	// have no handler.
+	topicsOut map[Key]*route
+	// middleware wraps every handler, outermost first.
+	middleware []Middleware
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		routes:    map[Key]*route{},
+		conns:     map[Transport]struct{}{},
+		topicsOut: map[Key]*route{},
+	}
+}
+
+// Handle registers fn to serve ep. Requests are decoded into Req and the
+// returned Resp is sent back with the request's sequence number.
+func Handle[Req, Resp any](d *Dispatcher, ep Endpoint[Req, Resp], fn func(ctx context.Context, req Req) (Resp, error)) error {
+	rt := &route{path: ep.Path, respKey: ep.RespKey}
+	rt.reqSchema, _ = postcard.SchemaFor[Req]()
+	rt.respSchema, _ = postcard.SchemaFor[Resp]()
+	rt.handler = func(ctx context.Context, r *Request) ([]byte, error) {
+		var req Req
+		if err := postcard.Deserialize(r.Body, &req); err != nil {
+			return nil, fmt.Errorf("%w: %w", DeserFailed{}, err)
+		}
+		resp, err := fn(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		// Serializing through a pointer keeps the static type, which enum
+		// responses need.
+		b, err := postcard.Serialize(&resp)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", SerFailed{}, err)
+		}
+		return b, nil
+	}
+	return d.register(ep.ReqKey, rt)
+}
+
+func (d *Dispatcher) register(key Key, r *route) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if old, ok := d.routes[key]; ok {
+		return fmt.Errorf("rpc: key %v of %q already used by %q", key, r.path, old.path)
+	}
+	d.routes[key] = r
+	return nil
+}
+
+// Dispatch handles one request frame and returns the reply frame, or nil
+// for topic messages. If handling fails, Dispatch returns the error along
+// with a WireError reply: the WireError in the error's chain if there is
+// one, FailedToSpawn otherwise. Failing topic messages get no reply.
+func (d *Dispatcher) Dispatch(ctx context.Context, frame []byte) ([]byte, error) {
+	hdr, body, err := DecodeFrame(frame)
+	if err != nil {
+		reply, _ := errorFrame(0, FrameTooShort{Len: uint32(len(frame))})
+		return reply, err
+	}
+	d.mu.RLock()
+	r := d.routes[hdr.Key]
+	middleware := d.middleware
+	d.mu.RUnlock()
+	if r == nil {
+		reply, _ := errorFrame(hdr.SeqNo, UnknownKey{})
+		return reply, fmt.Errorf("%w %v", ErrUnknownKey, hdr.Key)
+	}
+	h := r.handler
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	resp, err := h(ctx, &Request{Path: r.path, Header: hdr, Body: body})
+	if err != nil {
+		err = fmt.Errorf("rpc: %s: %w", r.path, err)
+		if r.topic {
+			return nil, err
+		}
+		var werr WireError
+		if !errors.As(err, &werr) {
+			werr = FailedToSpawn{}
+		}
+		reply, _ := errorFrame(hdr.SeqNo, werr)
+		return reply, err
+	}
+	if r.topic {
+		return nil, nil
+	}
+	return frameWithBody(WireHeader{Key: r.respKey, SeqNo: hdr.SeqNo}, resp)
+}
+
+// Serve reads request frames from t and sends the replies until receiving
+// fails or ctx ends, then closes t. Requests are handled concurrently.
+// Messages published with Publish are sent on every transport being served.
+func (d *Dispatcher) Serve(ctx context.Context, t Transport) error {
+	var wg sync.WaitGroup
+	// Handlers are cancelled before Serve waits for them.
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, transportKey{}, t))
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		t.Close()
+	}()
+
+	d.mu.Lock()
+	d.conns[t] = struct{}{}
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.conns, t)
+		d.mu.Unlock()
+	}()
+
+	for {
+		frame, err := t.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if reply, _ := d.Dispatch(ctx, frame); reply != nil {
+				t.Send(reply)
+			}
+		}()
+	}
+}
+
+// transportKey is the context key under which Serve stores the transport a
+// request arrived on.
+type transportKey struct{}
+
+// reply sends a topic message to the host whose request is being handled in
+// ctx, or to every host if the request did not come through Serve.
+func (d *Dispatcher) reply(ctx context.Context, key Key, body []byte) error {
+	t, ok := ctx.Value(transportKey{}).(Transport)
+	if !ok {
+		return d.publish(key, body)
+	}
+	frame, err := frameWithBody(WireHeader{Key: key, SeqNo: d.seq.Add(1)}, body)
+	if err != nil {
+		return err
+	}
+	return t.Send(frame)
+}
@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	h := WireHeader{Key: Key{1, 2, 3, 4, 5, 6, 7, 8}, SeqNo: 300}
+	frame, err := EncodeFrame(h, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{1, 2, 3, 4, 5, 6, 7, 8, 0xAC, 0x02, 0x02, 'h', 'i'}
+	if !bytes.Equal(frame, want) {
+		t.Fatalf("EncodeFrame = %x, want %x", frame, want)
+	}
+	got, body, err := DecodeFrame(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != h || !bytes.Equal(body, want[10:]) {
+		t.Errorf("DecodeFrame = %+v %x", got, body)
+	}
+	if _, _, err := DecodeFrame(want[:5]); !errors.Is(err, postcard.ErrDeserializeUnexpectedEnd) {
+		t.Errorf("DecodeFrame(short) error = %v", err)
+	}
+}
+
+var (
+	addEndpoint = MustEndpoint[pingPoint, int32]("math/add")
+	errOdd      = errors.New("odd")
+	// Enum endpoints are built after init has registered the enum.
+	shapeEndpoint Endpoint[uint8, keyShape]
+)
+
+func newTestDispatcher(t *testing.T) *Dispatcher {
+	shapeEndpoint = MustEndpoint[uint8, keyShape]("shape/get")
+	d := NewDispatcher()
+	err := Handle(d, addEndpoint, func(ctx context.Context, p pingPoint) (int32, error) {
+		if p.B%2 == 1 {
+			return 0, errOdd
+		}
+		return int32(p.A) + int32(p.B), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Handle(d, shapeEndpoint, func(ctx context.Context, n uint8) (keyShape, error) {
+		if n == 0 {
+			return keyEmpty{}, nil
+		}
+		return keyCircle{Radius: float32(n)}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDispatch(t *testing.T) {
+	d := newTestDispatcher(t)
+	ctx := context.Background()
+
+	req, _ := EncodeFrame(WireHeader{Key: addEndpoint.ReqKey, SeqNo: 7}, pingPoint{A: -3, B: 10})
+	reply, err := d.Dispatch(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdr, body, err := DecodeFrame(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr != (WireHeader{Key: addEndpoint.RespKey, SeqNo: 7}) {
+		t.Errorf("reply header = %+v", hdr)
+	}
+	var sum int32
+	if err := postcard.Deserialize(body, &sum); err != nil || sum != 7 {
+		t.Errorf("reply = %d, %v", sum, err)
+	}
+
+	req, _ = EncodeFrame(WireHeader{Key: shapeEndpoint.ReqKey, SeqNo: 1}, uint8(0))
+	reply, err = d.Dispatch(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, body, _ := DecodeFrame(reply); !bytes.Equal(body, []byte{0x01}) {
+		t.Errorf("enum reply = %x, want 01", body)
+	}
+
+	req, _ = EncodeFrame(WireHeader{Key: addEndpoint.ReqKey}, pingPoint{B: 1})
+	if _, err := d.Dispatch(ctx, req); !errors.Is(err, errOdd) {
+		t.Errorf("handler error = %v", err)
+	}
+
+	req, _ = EncodeFrame(WireHeader{Key: addEndpoint.RespKey}, pingPoint{})
+	if _, err := d.Dispatch(ctx, req); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown key error = %v", err)
+	}
+
+	req, _ = EncodeFrame(WireHeader{Key: addEndpoint.ReqKey}, int8(1))
+	if _, err := d.Dispatch(ctx, req); !errors.Is(err, postcard.ErrDeserializeUnexpectedEnd) {
+		t.Errorf("short body error = %v", err)
+	}
+
+	err = Handle(d, addEndpoint, func(ctx context.Context, p pingPoint) (int32, error) { return 0, nil })
+	if err == nil {
+		t.Error("registering a key twice succeeded")
+	}
+}
@@ -0,0 +1,31 @@
+package rpc
+
+// Endpoint describes a request/response pair served at Path.
+type Endpoint[Req, Resp any] struct {
+	Path    string
+	ReqKey  Key
+	RespKey Key
+}
+
+// NewEndpoint computes the keys of an endpoint from its path and types.
+func NewEndpoint[Req, Resp any](path string) (Endpoint[Req, Resp], error) {
+	reqKey, err := KeyFor[Req](path)
+	if err != nil {
+		return Endpoint[Req, Resp]{}, err
+	}
+	respKey, err := KeyFor[Resp](path)
+	if err != nil {
+		return Endpoint[Req, Resp]{}, err
+	}
+	return Endpoint[Req, Resp]{Path: path, ReqKey: reqKey, RespKey: respKey}, nil
+}
+
+// MustEndpoint is like NewEndpoint but panics if a type has no schema. It
+// simplifies declaring endpoints as package variables.
+func MustEndpoint[Req, Resp any](path string) Endpoint[Req, Resp] {
+	ep, err := NewEndpoint[Req, Resp](path)
+	if err != nil {
+		panic("rpc: endpoint " + path + ": " + err.Error())
+	}
+	return ep
+}
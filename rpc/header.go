@@ -0,0 +1,47 @@
+package rpc
+
+import "github.com/yixinin/postcard-go/postcard"
+
+// WireHeader prefixes every frame, like postcard-rpc's WireHeader: the key
+// as 8 raw bytes followed by the sequence number as a varint u32. Replies
+// carry the sequence number of their request.
+type WireHeader struct {
+	Key   Key
+	SeqNo uint32
+}
+
+// EncodeFrame serializes h followed by msg. T is the declared message type,
+// so registered enum interfaces encode their variant index.
+func EncodeFrame[T any](h WireHeader, msg T) ([]byte, error) {
+	s := postcard.NewSerializer(nil)
+	if err := s.SerializeValue(h); err != nil {
+		return nil, err
+	}
+	if err := s.SerializeValue(&msg); err != nil {
+		return nil, err
+	}
+	return s.Result()
+}
+
+// DecodeFrame splits a frame into its header and the encoded message.
+func DecodeFrame(frame []byte) (WireHeader, []byte, error) {
+	var h WireHeader
+	d := postcard.NewDeserializer(frame)
+	if err := d.DeserializeValue(&h); err != nil {
+		return WireHeader{}, nil, err
+	}
+	return h, d.Remaining(), nil
+}
+
+// frameWithBody serializes h followed by an already encoded message.
+func frameWithBody(h WireHeader, body []byte) ([]byte, error) {
+	s := postcard.NewSerializer(make([]byte, 0, 16+len(body)))
+	if err := s.SerializeValue(h); err != nil {
+		return nil, err
+	}
+	frame, err := s.Result()
+	if err != nil {
+		return nil, err
+	}
+	return append(frame, body...), nil
+}
@@ -0,0 +1,93 @@
+// Package rpc talks to devices running postcard-rpc. Endpoints and topics
+// are addressed by 8-byte keys derived from their path and schema exactly as
+// postcard-rpc derives them, so Go hosts and Rust firmware agree on keys
+// without exchanging them.
+package rpc
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+const (
+	fnvBasis = 0xcbf2_9ce4_8422_2325
+	fnvPrime = 0x0000_0100_0000_01b3
+)
+
+// Key identifies an endpoint request, response or topic message on the wire.
+type Key [8]byte
+
+func (k Key) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+// PostcardSchema describes Key as postcard-rpc's Key, a newtype around
+// [u8; 8].
+func (Key) PostcardSchema() (*postcard.Schema, error) {
+	return postcard.NewtypeStructSchema[[8]byte]("Key")
+}
+
+// KeyForPath hashes path and schema with 64-bit FNV-1a the way postcard-rpc's
+// Key::for_path does. Type names are not hashed, so structurally identical
+// types with different names produce the same key.
+func KeyForPath(path string, schema *postcard.Schema) Key {
+	state := hashUpdate(fnvBasis, []byte(path))
+	state = hashSchema(state, schema)
+	var k Key
+	binary.LittleEndian.PutUint64(k[:], state)
+	return k
+}
+
+// KeyFor returns the key for messages of type T sent on path.
+func KeyFor[T any](path string) (Key, error) {
+	schema, err := postcard.SchemaFor[T]()
+	if err != nil {
+		return Key{}, err
+	}
+	return KeyForPath(path, schema), nil
+}
+
+func hashUpdate(state uint64, data []byte) uint64 {
+	for _, b := range data {
+		state ^= uint64(b)
+		state *= fnvPrime
+	}
+	return state
+}
+
+func hashSchema(state uint64, s *postcard.Schema) uint64 {
+	state = hashUpdate(state, []byte{byte(s.Kind)})
+	switch s.Kind {
+	case postcard.SchemaOption, postcard.SchemaNewtypeStruct, postcard.SchemaSeq,
+		postcard.SchemaTuple, postcard.SchemaTupleStruct, postcard.SchemaMap:
+		for _, e := range s.Elems {
+			state = hashSchema(state, e)
+		}
+	case postcard.SchemaStruct:
+		state = hashFields(state, s.Fields)
+	case postcard.SchemaEnum:
+		for _, v := range s.Variants {
+			state = hashUpdate(state, []byte(v.Name))
+			state = hashUpdate(state, []byte{byte(v.Kind)})
+			switch v.Kind {
+			case postcard.VariantNewtype, postcard.VariantTuple:
+				for _, e := range v.Elems {
+					state = hashSchema(state, e)
+				}
+			case postcard.VariantStruct:
+				state = hashFields(state, v.Fields)
+			}
+		}
+	}
+	return state
+}
+
+func hashFields(state uint64, fields []postcard.SchemaField) uint64 {
+	for _, f := range fields {
+		state = hashUpdate(state, []byte(f.Name))
+		state = hashSchema(state, f.Type)
+	}
+	return state
+}
@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"testing"
+
+	device "github.com/yixinin/postcard-go/internal/rs2gotest"
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+func TestHashUpdate(t *testing.T) {
+	tests := []struct {
+		input string
+		want  uint64
+	}{
+		{"", 0xcbf29ce484222325},
+		{"a", 0xaf63dc4c8601ec8c},
+		{"foobar", 0x85944171f73967e8},
+	}
+	for _, tt := range tests {
+		if got := hashUpdate(fnvBasis, []byte(tt.input)); got != tt.want {
+			t.Errorf("hashUpdate(%q) = %#x, want %#x", tt.input, got, tt.want)
+		}
+	}
+}
+
+// fnvKey hashes data with the standard library for comparison.
+func fnvKey(data []byte) Key {
+	h := fnv.New64a()
+	h.Write(data)
+	var k Key
+	binary.LittleEndian.PutUint64(k[:], h.Sum64())
+	return k
+}
+
+type pingPoint struct {
+	A int8
+	B uint32
+}
+
+type keyShape interface{ isKeyShape() }
+
+type keyCircle struct{ Radius float32 }
+type keyEmpty struct{}
+
+func (keyCircle) isKeyShape() {}
+func (keyEmpty) isKeyShape()  {}
+
+func init() {
+	postcard.RegisterEnum[keyShape](keyCircle{}, keyEmpty{})
+}
+
+func TestKeyFor(t *testing.T) {
+	check := func(name string, got Key, err error, data []byte) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if want := fnvKey(data); got != want {
+			t.Errorf("%s: key = %v, want %v", name, got, want)
+		}
+	}
+
+	k, err := KeyFor[uint32]("ping")
+	check("u32", k, err, []byte("ping\x08"))
+
+	k, err = KeyFor[pingPoint]("point")
+	check("struct", k, err, []byte("point\x1aa\x01b\x08"))
+
+	k, err = KeyFor[[]postcard.Option[string]]("list")
+	check("seq", k, err, []byte("list\x16\x12\x10"))
+
+	k, err = KeyFor[keyShape]("shape")
+	check("enum", k, err, []byte("shape\x1bkeyCircle\x03radius\x0dkeyEmpty\x00"))
+}
+
+func TestKeyForUnsupported(t *testing.T) {
+	if _, err := KeyFor[chan int]("bad"); err == nil {
+		t.Error("KeyFor[chan int] succeeded")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustEndpoint did not panic")
+		}
+	}()
+	MustEndpoint[uint8, func()]("bad")
+}
+
+func TestNewEndpoint(t *testing.T) {
+	ep, err := NewEndpoint[uint32, pingPoint]("ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep.ReqKey != fnvKey([]byte("ping\x08")) {
+		t.Errorf("ReqKey = %v", ep.ReqKey)
+	}
+	if ep.RespKey != fnvKey([]byte("ping\x1aa\x01b\x08")) {
+		t.Errorf("RespKey = %v", ep.RespKey)
+	}
+}
+
+// TestGoldenKeys checks keys against literals worked out apart from this
+// package by testdata/keys.rs, a standalone Rust program that builds
+// postcard-schema DataModelType trees for the Rust declarations by hand, as
+// #[derive(Schema)] does, and hashes them like postcard-rpc's hash_ty_path.
+// Its header says how to run it. The device types are postcard-rs2go's output
+// for cmd/postcard-rs2go/testdata/device.rs.
+func TestGoldenKeys(t *testing.T) {
+	command, err := NewEndpoint[device.Command, device.Reply]("device/command")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ack, err := NewTopic[device.Ack]("device/ack")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  Key
+		want Key
+	}{
+		{"ping request", PingEndpoint.ReqKey, Key{0x50, 0x18, 0x6c, 0xf2, 0x24, 0xb4, 0xed, 0xe8}},
+		{"ping response", PingEndpoint.RespKey, Key{0x50, 0x18, 0x6c, 0xf2, 0x24, 0xb4, 0xed, 0xe8}},
+		{"error", ErrorKey, Key{0xe9, 0x92, 0x36, 0x89, 0x6e, 0x28, 0x86, 0x38}},
+		{"command request", command.ReqKey, Key{0x64, 0x0d, 0x8e, 0xec, 0xfc, 0x49, 0x39, 0xf1}},
+		{"command response", command.RespKey, Key{0xc5, 0xec, 0xaf, 0xc3, 0xe1, 0xa7, 0x9d, 0xc1}},
+		{"ack topic", ack.Key, Key{0xc3, 0xd7, 0xa9, 0x41, 0x45, 0xf4, 0x5e, 0xc0}},
+		{"schemas request", GetAllSchemasEndpoint.ReqKey, Key{0x5c, 0x9d, 0x2b, 0x18, 0x59, 0x33, 0x29, 0x7a}},
+		{"schemas response", GetAllSchemasEndpoint.RespKey, Key{0x5e, 0xf3, 0xc1, 0x5e, 0x32, 0x0d, 0x56, 0xe7}},
+		{"schema data topic", GetAllSchemaDataTopic.Key, Key{0xed, 0x6c, 0x88, 0x3d, 0xeb, 0x18, 0x36, 0x9b}},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: key = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
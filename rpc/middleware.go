@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Middleware wraps a Handler with behaviour shared by every endpoint and
+// topic, such as logging or access checks. Topic handlers return a nil
+// response.
+type Middleware func(next Handler) Handler
+
+// Use adds middleware to every handler of d, including those registered
+// later. The first middleware added is the outermost.
+func (d *Dispatcher) Use(mw ...Middleware) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// Copy so Dispatch can keep using a snapshot without the lock.
+	d.middleware = append(d.middleware[:len(d.middleware):len(d.middleware)], mw...)
+}
+
+var ErrHandlerPanic = errors.New("rpc: handler panicked")
+
+// Recover turns a panicking handler into an error wrapping ErrHandlerPanic,
+// so the request fails with a WireError instead of crashing the process.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (resp []byte, err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					err = fmt.Errorf("%w: %v", ErrHandlerPanic, p)
+				}
+			}()
+			return next(ctx, req)
+		}
+	}
+}
+
+// Logger logs every request to l: successes at debug level and failures at
+// error level, with the path, key, sequence number, sizes and duration.
+func Logger(l *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			attrs := []slog.Attr{
+				slog.String("path", req.Path),
+				slog.String("key", req.Header.Key.String()),
+				slog.Uint64("seq", uint64(req.Header.SeqNo)),
+				slog.Int("req_bytes", len(req.Body)),
+				slog.Int("resp_bytes", len(resp)),
+				slog.Duration("elapsed", time.Since(start)),
+			}
+			level := slog.LevelDebug
+			if err != nil {
+				level = slog.LevelError
+				attrs = append(attrs, slog.Any("error", err))
+			}
+			l.LogAttrs(ctx, level, "rpc request", attrs...)
+			return resp, err
+		}
+	}
+}
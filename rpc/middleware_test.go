@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+var panicEndpoint = MustEndpoint[uint8, uint8]("test/panic")
+
+func TestMiddlewareOrder(t *testing.T) {
+	d := newTestDispatcher(t)
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) ([]byte, error) {
+				calls = append(calls, name+" "+req.Path)
+				return next(ctx, req)
+			}
+		}
+	}
+	d.Use(trace("outer"))
+	d.Use(trace("inner"))
+
+	req := mustFrame(t, WireHeader{Key: addEndpoint.ReqKey, SeqNo: 1}, pingPoint{A: 1, B: 2})
+	if _, err := d.Dispatch(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"outer math/add", "inner math/add"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestMiddlewareReject(t *testing.T) {
+	d := newTestDispatcher(t)
+	errDenied := errors.New("denied")
+	d.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			if req.Header.Key == addEndpoint.ReqKey && len(req.Body) > 0 && req.Body[0] == 0xFF {
+				return nil, errDenied
+			}
+			return next(ctx, req)
+		}
+	})
+	c, _ := startPipe(t, d)
+	if _, err := Call(context.Background(), c, addEndpoint, pingPoint{A: -1}); !errors.As(err, new(FailedToSpawn)) {
+		t.Errorf("rejected call error = %v", err)
+	}
+	if sum, err := Call(context.Background(), c, addEndpoint, pingPoint{A: 1}); err != nil || sum != 1 {
+		t.Errorf("allowed call = %d, %v", sum, err)
+	}
+}
+
+func TestRecoverAndLogger(t *testing.T) {
+	d := newTestDispatcher(t)
+	err := Handle(d, panicEndpoint, func(ctx context.Context, n uint8) (uint8, error) {
+		panic("boom")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	d.Use(Logger(logger), Recover())
+	ctx := context.Background()
+
+	reply, err := d.Dispatch(ctx, mustFrame(t, WireHeader{Key: panicEndpoint.ReqKey, SeqNo: 3}, uint8(1)))
+	if !errors.Is(err, ErrHandlerPanic) {
+		t.Fatalf("Dispatch error = %v, want ErrHandlerPanic", err)
+	}
+	if werr := decodeErrorReply(t, reply, 3); werr != (FailedToSpawn{}) {
+		t.Errorf("reply = %#v", werr)
+	}
+	if _, err := d.Dispatch(ctx, mustFrame(t, WireHeader{Key: addEndpoint.ReqKey, SeqNo: 4}, pingPoint{A: 2})); err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []map[string]interface{}
+	dec := json.NewDecoder(&logs)
+	for dec.More() {
+		var e map[string]interface{}
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("logged %d entries, want 2", len(entries))
+	}
+	if e := entries[0]; e["level"] != "ERROR" || e["path"] != "test/panic" || e["seq"] != 3.0 || e["error"] == nil {
+		t.Errorf("panic entry = %v", e)
+	}
+	if e := entries[1]; e["level"] != "DEBUG" || e["path"] != "math/add" || e["key"] != addEndpoint.ReqKey.String() || e["resp_bytes"] != 1.0 {
+		t.Errorf("success entry = %v", e)
+	}
+}
@@ -0,0 +1,253 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+// NamedType carries a postcard.Schema on the wire, encoded like
+// postcard-schema's OwnedNamedType: the name, then the DataModelType as an
+// enum whose variant indexes are the SchemaKind values, holding the inner
+// types, fields or variants of the kinds that have them.
+type NamedType struct {
+	Schema *postcard.Schema
+}
+
+var errBadSchema = errors.New("rpc: malformed schema")
+
+// maxSchemaDepth bounds the nesting of a decoded schema, which no real type
+// comes near, so hostile input cannot exhaust the stack.
+const maxSchemaDepth = 64
+
+func (n NamedType) MarshalPostcard(s *postcard.Serializer) error {
+	if n.Schema == nil {
+		return fmt.Errorf("%w: nil schema", errBadSchema)
+	}
+	return marshalNamedType(s, n.Schema)
+}
+
+func (n *NamedType) UnmarshalPostcard(d *postcard.Deserializer) error {
+	schema, err := unmarshalNamedType(d, 0)
+	if err != nil {
+		return err
+	}
+	n.Schema = schema
+	return nil
+}
+
+// PostcardSchema describes NamedType the way postcard-schema describes its
+// own types, as DataModelType::Schema.
+func (NamedType) PostcardSchema() (*postcard.Schema, error) {
+	return &postcard.Schema{Name: "OwnedNamedType", Kind: postcard.SchemaSchema}, nil
+}
+
+func marshalNamedType(s *postcard.Serializer, n *postcard.Schema) error {
+	if err := s.SerializeString(n.Name); err != nil {
+		return err
+	}
+	if err := s.SerializeEnum(uint32(n.Kind), nil); err != nil {
+		return err
+	}
+	switch n.Kind {
+	case postcard.SchemaOption, postcard.SchemaNewtypeStruct, postcard.SchemaSeq:
+		if len(n.Elems) != 1 {
+			return fmt.Errorf("%w: %s needs one inner type, has %d", errBadSchema, n.Name, len(n.Elems))
+		}
+		return marshalNamedType(s, n.Elems[0])
+	case postcard.SchemaMap:
+		if len(n.Elems) != 2 {
+			return fmt.Errorf("%w: map %s needs a key and a value type, has %d", errBadSchema, n.Name, len(n.Elems))
+		}
+		if err := marshalNamedType(s, n.Elems[0]); err != nil {
+			return err
+		}
+		return marshalNamedType(s, n.Elems[1])
+	case postcard.SchemaTuple, postcard.SchemaTupleStruct:
+		return marshalNamedTypes(s, n.Elems)
+	case postcard.SchemaStruct:
+		return marshalFields(s, n.Fields)
+	case postcard.SchemaEnum:
+		if err := s.SerializeUint(uint(len(n.Variants))); err != nil {
+			return err
+		}
+		for _, v := range n.Variants {
+			if err := s.SerializeString(v.Name); err != nil {
+				return err
+			}
+			if err := s.SerializeEnum(uint32(v.Kind), nil); err != nil {
+				return err
+			}
+			var err error
+			switch v.Kind {
+			case postcard.VariantUnit:
+			case postcard.VariantNewtype:
+				if len(v.Elems) != 1 {
+					return fmt.Errorf("%w: newtype variant %s needs one inner type, has %d", errBadSchema, v.Name, len(v.Elems))
+				}
+				err = marshalNamedType(s, v.Elems[0])
+			case postcard.VariantTuple:
+				err = marshalNamedTypes(s, v.Elems)
+			case postcard.VariantStruct:
+				err = marshalFields(s, v.Fields)
+			default:
+				err = fmt.Errorf("%w: variant %s has kind %d", errBadSchema, v.Name, v.Kind)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case postcard.SchemaSchema:
+		return nil
+	}
+	if n.Kind > postcard.SchemaSchema {
+		return fmt.Errorf("%w: %s has kind %d", errBadSchema, n.Name, n.Kind)
+	}
+	return nil
+}
+
+func marshalNamedTypes(s *postcard.Serializer, elems []*postcard.Schema) error {
+	if err := s.SerializeUint(uint(len(elems))); err != nil {
+		return err
+	}
+	for _, e := range elems {
+		if err := marshalNamedType(s, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func marshalFields(s *postcard.Serializer, fields []postcard.SchemaField) error {
+	if err := s.SerializeUint(uint(len(fields))); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if err := s.SerializeString(f.Name); err != nil {
+			return err
+		}
+		if err := marshalNamedType(s, f.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalNamedType(d *postcard.Deserializer, depth int) (*postcard.Schema, error) {
+	if depth > maxSchemaDepth {
+		return nil, fmt.Errorf("%w: nested deeper than %d", errBadSchema, maxSchemaDepth)
+	}
+	name, err := d.DeserializeString()
+	if err != nil {
+		return nil, err
+	}
+	var kind uint32
+	if err := d.DeserializeEnum(&kind, nil); err != nil {
+		return nil, err
+	}
+	if kind > uint32(postcard.SchemaSchema) {
+		return nil, fmt.Errorf("%w: %s has kind %d", errBadSchema, name, kind)
+	}
+	n := &postcard.Schema{Name: name, Kind: postcard.SchemaKind(kind)}
+	switch n.Kind {
+	case postcard.SchemaOption, postcard.SchemaNewtypeStruct, postcard.SchemaSeq:
+		elem, err := unmarshalNamedType(d, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		n.Elems = []*postcard.Schema{elem}
+	case postcard.SchemaMap:
+		for i := 0; i < 2; i++ {
+			elem, err := unmarshalNamedType(d, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			n.Elems = append(n.Elems, elem)
+		}
+	case postcard.SchemaTuple, postcard.SchemaTupleStruct:
+		if n.Elems, err = unmarshalNamedTypes(d, depth+1); err != nil {
+			return nil, err
+		}
+	case postcard.SchemaStruct:
+		if n.Fields, err = unmarshalFields(d, depth+1); err != nil {
+			return nil, err
+		}
+	case postcard.SchemaEnum:
+		count, err := d.DeserializeUint()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint(0); i < count; i++ {
+			v := postcard.SchemaVariant{}
+			if v.Name, err = d.DeserializeString(); err != nil {
+				return nil, err
+			}
+			var kind uint32
+			if err := d.DeserializeEnum(&kind, nil); err != nil {
+				return nil, err
+			}
+			v.Kind = postcard.VariantKind(kind)
+			switch v.Kind {
+			case postcard.VariantUnit:
+			case postcard.VariantNewtype:
+				elem, err := unmarshalNamedType(d, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				v.Elems = []*postcard.Schema{elem}
+			case postcard.VariantTuple:
+				if v.Elems, err = unmarshalNamedTypes(d, depth+1); err != nil {
+					return nil, err
+				}
+			case postcard.VariantStruct:
+				if v.Fields, err = unmarshalFields(d, depth+1); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("%w: variant %s has kind %d", errBadSchema, v.Name, kind)
+			}
+			n.Variants = append(n.Variants, v)
+		}
+	}
+	return n, nil
+}
+
+// unmarshalNamedTypes reads a length-prefixed list of types. Each takes at
+// least two bytes, so the length is not trusted for allocation.
+func unmarshalNamedTypes(d *postcard.Deserializer, depth int) ([]*postcard.Schema, error) {
+	count, err := d.DeserializeUint()
+	if err != nil {
+		return nil, err
+	}
+	elems := make([]*postcard.Schema, 0, d.PreallocLen(count))
+	for i := uint(0); i < count; i++ {
+		elem, err := unmarshalNamedType(d, depth)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+	}
+	return elems, nil
+}
+
+func unmarshalFields(d *postcard.Deserializer, depth int) ([]postcard.SchemaField, error) {
+	count, err := d.DeserializeUint()
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]postcard.SchemaField, 0, d.PreallocLen(count))
+	for i := uint(0); i < count; i++ {
+		name, err := d.DeserializeString()
+		if err != nil {
+			return nil, err
+		}
+		ty, err := unmarshalNamedType(d, depth)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, postcard.SchemaField{Name: name, Type: ty})
+	}
+	return fields, nil
+}
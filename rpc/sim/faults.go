@@ -0,0 +1,247 @@
+package sim
+
+import (
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/yixinin/postcard-go/rpc"
+)
+
+// Direction selects the frames a fault applies to, seen from the wrapped
+// transport: Outbound frames are sent on it and Inbound frames received.
+type Direction int
+
+const (
+	Inbound Direction = 1 << iota
+	Outbound
+	Both = Inbound | Outbound
+)
+
+// Selector picks frames by their 0-based index in one direction.
+type Selector func(n int) bool
+
+// Always selects every frame.
+func Always() Selector {
+	return func(int) bool { return true }
+}
+
+// Nth selects the frames with the given indexes.
+func Nth(ns ...int) Selector {
+	return func(n int) bool {
+		for _, m := range ns {
+			if n == m {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// EveryNth selects frames k-1, 2k-1, ... It selects none if k is not
+// positive.
+func EveryNth(k int) Selector {
+	if k <= 0 {
+		return func(int) bool { return false }
+	}
+	return func(n int) bool { return (n+1)%k == 0 }
+}
+
+// Random selects each frame with probability p. The seed makes runs
+// repeatable.
+func Random(p float64, seed int64) Selector {
+	var mu sync.Mutex
+	r := rand.New(rand.NewSource(seed))
+	return func(int) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return r.Float64() < p
+	}
+}
+
+type faultKind int
+
+const (
+	faultDrop faultKind = iota
+	faultCorrupt
+	faultDelay
+	faultReorder
+)
+
+// Fault is one rule of a fault script.
+type Fault struct {
+	kind  faultKind
+	dir   Direction
+	when  Selector
+	delay time.Duration
+}
+
+// Drop discards the selected frames.
+func Drop(dir Direction, when Selector) Fault {
+	return Fault{kind: faultDrop, dir: dir, when: when}
+}
+
+// Corrupt inverts the last byte of the selected frames, which usually lands
+// in the message body.
+func Corrupt(dir Direction, when Selector) Fault {
+	return Fault{kind: faultCorrupt, dir: dir, when: when}
+}
+
+// Delay holds the selected frames back for d. Delayed outbound frames are
+// overtaken by the frames sent after them; inbound delays stall the Recv
+// call that got the frame, until d passes or the transport is closed.
+func Delay(dir Direction, when Selector, d time.Duration) Fault {
+	return Fault{kind: faultDelay, dir: dir, when: when, delay: d}
+}
+
+// Reorder swaps each selected frame with the frame that follows it. A held
+// frame is lost if no other frame follows.
+func Reorder(dir Direction, when Selector) Fault {
+	return Fault{kind: faultReorder, dir: dir, when: when}
+}
+
+// effect is what the script decided for one frame.
+type effect struct {
+	drop    bool
+	delay   time.Duration
+	reorder bool
+}
+
+// faultTransport applies a fault script to the frames of a transport.
+type faultTransport struct {
+	rpc.Transport
+	faults []Fault
+
+	sendMu   sync.Mutex
+	sent     int
+	sendHeld []byte
+
+	recvMu   sync.Mutex
+	received int
+	recvHeld []byte
+	recvNext []byte
+
+	// closed is closed by Close, ending inbound delays.
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// WithFaults wraps t so the frames passing through it suffer faults. Every
+// fault whose direction and selector match a frame applies to it.
+func WithFaults(t rpc.Transport, faults ...Fault) rpc.Transport {
+	return &faultTransport{Transport: t, faults: faults, closed: make(chan struct{})}
+}
+
+func (t *faultTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return t.Transport.Close()
+}
+
+func (t *faultTransport) apply(dir Direction, n int, frame []byte) ([]byte, effect) {
+	var e effect
+	for _, f := range t.faults {
+		if f.dir&dir == 0 || !f.when(n) {
+			continue
+		}
+		switch f.kind {
+		case faultDrop:
+			e.drop = true
+		case faultCorrupt:
+			if len(frame) > 0 {
+				frame = append([]byte(nil), frame...)
+				frame[len(frame)-1] ^= 0xFF
+			}
+		case faultDelay:
+			e.delay += f.delay
+		case faultReorder:
+			e.reorder = true
+		}
+	}
+	return frame, e
+}
+
+func (t *faultTransport) Send(frame []byte) error {
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+	frame, e := t.apply(Outbound, t.sent, frame)
+	t.sent++
+	switch {
+	case e.drop:
+		return nil
+	case e.delay > 0:
+		frame = append([]byte(nil), frame...)
+		time.AfterFunc(e.delay, func() { t.Transport.Send(frame) })
+		return nil
+	case e.reorder && t.sendHeld == nil:
+		t.sendHeld = append([]byte(nil), frame...)
+		return nil
+	}
+	if err := t.Transport.Send(frame); err != nil {
+		return err
+	}
+	if held := t.sendHeld; held != nil {
+		t.sendHeld = nil
+		return t.Transport.Send(held)
+	}
+	return nil
+}
+
+func (t *faultTransport) Recv() ([]byte, error) {
+	for {
+		frame, e, err := t.recv()
+		if err != nil {
+			return nil, err
+		}
+		if e.drop {
+			continue
+		}
+		if e.delay > 0 {
+			// Waiting without recvMu lets Close and other receivers proceed.
+			timer := time.NewTimer(e.delay)
+			select {
+			case <-timer.C:
+			case <-t.closed:
+				timer.Stop()
+				return nil, io.ErrClosedPipe
+			}
+		}
+		if frame, ok := t.reorder(frame, e); ok {
+			return frame, nil
+		}
+	}
+}
+
+// recv takes the next frame, a frame a reorder held back or a new one, and
+// decides what the script does to it.
+func (t *faultTransport) recv() ([]byte, effect, error) {
+	t.recvMu.Lock()
+	defer t.recvMu.Unlock()
+	if next := t.recvNext; next != nil {
+		t.recvNext = nil
+		return next, effect{}, nil
+	}
+	frame, err := t.Transport.Recv()
+	if err != nil {
+		return nil, effect{}, err
+	}
+	frame, e := t.apply(Inbound, t.received, frame)
+	t.received++
+	return frame, e, nil
+}
+
+// reorder holds frame back if the script says so, and otherwise queues any
+// held frame to follow it. It reports whether frame is to be returned.
+func (t *faultTransport) reorder(frame []byte, e effect) ([]byte, bool) {
+	t.recvMu.Lock()
+	defer t.recvMu.Unlock()
+	if e.reorder && t.recvHeld == nil {
+		t.recvHeld = frame
+		return nil, false
+	}
+	if held := t.recvHeld; held != nil {
+		t.recvHeld = nil
+		t.recvNext = held
+	}
+	return frame, true
+}
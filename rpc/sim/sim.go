@@ -0,0 +1,75 @@
+// Package sim simulates postcard-rpc devices so host code can be tested
+// without hardware. A Device serves handlers and periodic topics over any
+// rpc.Transport, and WithFaults wraps a transport to drop, corrupt, delay or
+// reorder frames on a script.
+package sim
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yixinin/postcard-go/rpc"
+)
+
+// Device is a simulated device. Register handlers on the embedded
+// Dispatcher with rpc.Handle and rpc.HandleTopic.
+type Device struct {
+	*rpc.Dispatcher
+
+	mu         sync.Mutex
+	publishers []func(ctx context.Context)
+}
+
+// NewDevice returns a device serving the standard endpoints.
+func NewDevice() *Device {
+	d := &Device{Dispatcher: rpc.NewDispatcher()}
+	if err := rpc.RegisterStandard(d.Dispatcher); err != nil {
+		panic("sim: " + err.Error())
+	}
+	return d
+}
+
+// Every makes d publish next() on t every interval while it is serving. The
+// topic is listed by the standard endpoints.
+func Every[T any](d *Device, t rpc.Topic[T], interval time.Duration, next func() T) error {
+	if err := rpc.DeclareTopic(d.Dispatcher, t); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.publishers = append(d.publishers, func(ctx context.Context) {
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+				rpc.Publish(d.Dispatcher, t, next())
+			}
+		}
+	})
+	return nil
+}
+
+// Serve serves t until ctx ends or t fails, running the periodic publishers
+// meanwhile. Topics go to every transport being served, so a device
+// normally serves one at a time.
+func (d *Device) Serve(ctx context.Context, t rpc.Transport) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	d.mu.Lock()
+	for _, p := range d.publishers {
+		wg.Add(1)
+		go func(p func(context.Context)) {
+			defer wg.Done()
+			p(ctx)
+		}(p)
+	}
+	d.mu.Unlock()
+	return d.Dispatcher.Serve(ctx, t)
+}
@@ -0,0 +1,176 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yixinin/postcard-go/rpc"
+)
+
+var (
+	echoEndpoint = rpc.MustEndpoint[uint32, uint32]("sim/echo")
+	countTopic   = rpc.MustTopic[uint32]("sim/count")
+)
+
+// start serves a device through the fault script and returns a host client.
+// The device publishes on countTopic only if publish is set, since topic
+// frames shift the frame indexes faults select.
+func start(t *testing.T, publish bool, faults ...Fault) *rpc.Client {
+	t.Helper()
+	dev := NewDevice()
+	err := rpc.Handle(dev.Dispatcher, echoEndpoint, func(ctx context.Context, n uint32) (uint32, error) {
+		return n, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publish {
+		var count atomic.Uint32
+		if err := Every(dev, countTopic, 5*time.Millisecond, func() uint32 { return count.Add(1) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	host, device := rpc.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		dev.Serve(ctx, WithFaults(device, faults...))
+		close(done)
+	}()
+	c := rpc.NewClient(host)
+	t.Cleanup(func() {
+		c.Close()
+		cancel()
+		<-done
+	})
+	return c
+}
+
+func call(c *rpc.Client, n uint32, timeout time.Duration) (uint32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return rpc.Call(ctx, c, echoEndpoint, n)
+}
+
+func TestDevice(t *testing.T) {
+	c := start(t, true)
+	ctx := context.Background()
+	schemas, err := rpc.GetSchemas(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rpc.Supports(schemas, echoEndpoint) || len(schemas.TopicsOut) != 1 || schemas.TopicsOut[0].Key != countTopic.Key {
+		t.Errorf("GetSchemas = %+v", schemas)
+	}
+
+	sub := rpc.Subscribe(c, countTopic, 16, rpc.DropOldest)
+	var last uint32
+	for i := 0; i < 3; i++ {
+		select {
+		case n := <-sub.C:
+			if n <= last {
+				t.Errorf("count went from %d to %d", last, n)
+			}
+			last = n
+		case <-time.After(time.Second):
+			t.Fatal("no topic message")
+		}
+	}
+}
+
+func TestDropAndCorrupt(t *testing.T) {
+	c := start(t, false, Drop(Outbound, Nth(0)), Corrupt(Outbound, Nth(1)))
+	if _, err := call(c, 1, 50*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("dropped reply: error = %v", err)
+	}
+	// The echoed 5 becomes 0xFA, a varint that never ends.
+	if _, err := call(c, 5, time.Second); err == nil {
+		t.Error("corrupted reply decoded")
+	}
+	if n, err := call(c, 7, time.Second); err != nil || n != 7 {
+		t.Errorf("call after faults = %d, %v", n, err)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	c := start(t, false, Delay(Outbound, Nth(0), 50*time.Millisecond))
+	begin := time.Now()
+	if n, err := call(c, 3, time.Second); err != nil || n != 3 {
+		t.Fatalf("call = %d, %v", n, err)
+	}
+	if elapsed := time.Since(begin); elapsed < 50*time.Millisecond {
+		t.Errorf("delayed reply arrived after %v", elapsed)
+	}
+	if _, err := call(c, 4, 30*time.Millisecond); err != nil {
+		t.Errorf("undelayed call: %v", err)
+	}
+}
+
+func TestDelayInboundClose(t *testing.T) {
+	host, device := rpc.Pipe()
+	defer host.Close()
+	ft := WithFaults(device, Delay(Inbound, Always(), time.Hour))
+	if err := host.Send([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		_, err := ft.Recv()
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	ft.Close()
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("Recv returned the delayed frame after Close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not end the inbound delay")
+	}
+}
+
+func TestReorder(t *testing.T) {
+	c := start(t, false, Reorder(Inbound, Nth(0)))
+	first := make(chan error, 1)
+	go func() {
+		_, err := call(c, 1, time.Second)
+		first <- err
+	}()
+	select {
+	case err := <-first:
+		t.Fatalf("held request was answered: %v", err)
+	case <-time.After(30 * time.Millisecond):
+	}
+	if n, err := call(c, 2, time.Second); err != nil || n != 2 {
+		t.Errorf("second call = %d, %v", n, err)
+	}
+	if err := <-first; err != nil {
+		t.Errorf("first call: %v", err)
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	a, b := Random(0.5, 42), Random(0.5, 42)
+	for i := 0; i < 100; i++ {
+		if a(i) != b(i) {
+			t.Fatal("Random is not repeatable")
+		}
+	}
+	every := EveryNth(3)
+	if every(0) || every(1) || !every(2) || !every(5) {
+		t.Error("EveryNth(3) selects the wrong frames")
+	}
+	for _, k := range []int{0, -2} {
+		if EveryNth(k)(0) || EveryNth(k)(5) {
+			t.Errorf("EveryNth(%d) selects frames", k)
+		}
+	}
+	if !Always()(9) || Nth(1, 4)(2) || !Nth(1, 4)(4) {
+		t.Error("Always or Nth select the wrong frames")
+	}
+}
@@ -0,0 +1,277 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+// SchemaTotals is the reply to GetAllSchemasEndpoint: how many entries of
+// each kind the device sent on GetAllSchemaDataTopic, and how many it failed
+// to send.
+type SchemaTotals struct {
+	TypesSent     uint32
+	EndpointsSent uint32
+	TopicsInSent  uint32
+	TopicsOutSent uint32
+	Errors        uint32
+}
+
+// TopicDirection tells which way a topic's messages travel: ToServer topics
+// are handled by the device and ToClient topics are published by it.
+type TopicDirection uint32
+
+const (
+	TopicToServer TopicDirection = iota
+	TopicToClient
+)
+
+func (TopicDirection) PostcardSchema() (*postcard.Schema, error) {
+	return postcard.UnitEnumSchema("TopicDirection", "ToServer", "ToClient"), nil
+}
+
+// SchemaData is one entry of a device's schema listing: a type used by its
+// endpoints and topics, or one of the endpoints or topics themselves.
+type SchemaData interface {
+	isSchemaData()
+}
+
+// SchemaDataType carries the schema of a message type.
+type SchemaDataType struct {
+	Value NamedType
+}
+
+// SchemaDataEndpoint names an endpoint the device serves.
+type SchemaDataEndpoint struct {
+	Path        string
+	RequestKey  Key
+	ResponseKey Key
+}
+
+// SchemaDataTopic names a topic the device handles or publishes.
+type SchemaDataTopic struct {
+	Path      string
+	Key       Key
+	Direction TopicDirection
+}
+
+func (SchemaDataType) isSchemaData()     {}
+func (SchemaDataEndpoint) isSchemaData() {}
+func (SchemaDataTopic) isSchemaData()    {}
+
+func (SchemaDataType) PostcardVariant() (postcard.SchemaVariant, error) {
+	return postcard.NewtypeVariantSchema[NamedType]("Type")
+}
+
+func (SchemaDataEndpoint) PostcardVariant() (postcard.SchemaVariant, error) {
+	return postcard.StructVariantSchema[SchemaDataEndpoint]("Endpoint")
+}
+
+func (SchemaDataTopic) PostcardVariant() (postcard.SchemaVariant, error) {
+	return postcard.StructVariantSchema[SchemaDataTopic]("Topic")
+}
+
+// The standard endpoints and topics of postcard-rpc's ICD, served by
+// RegisterStandard. A request to GetAllSchemasEndpoint makes the device send
+// its whole schema listing on GetAllSchemaDataTopic before replying with the
+// totals.
+var (
+	PingEndpoint          = MustEndpoint[uint32, uint32]("postcard-rpc/ping")
+	GetAllSchemasEndpoint = MustEndpoint[postcard.Unit, SchemaTotals]("postcard-rpc/schemas/get")
+	// GetAllSchemaDataTopic is set once SchemaData is registered.
+	GetAllSchemaDataTopic Topic[SchemaData]
+)
+
+func init() {
+	postcard.RegisterEnum[SchemaData](SchemaDataType{}, SchemaDataEndpoint{}, SchemaDataTopic{})
+	GetAllSchemaDataTopic = MustTopic[SchemaData]("postcard-rpc/schema/data")
+}
+
+// RegisterStandard adds the standard endpoints to d: ping echoes its
+// argument, and the schema listing describes every endpoint and topic
+// registered on d, along with their message types.
+func RegisterStandard(d *Dispatcher) error {
+	err := Handle(d, PingEndpoint, func(ctx context.Context, n uint32) (uint32, error) {
+		return n, nil
+	})
+	if err != nil {
+		return err
+	}
+	return Handle(d, GetAllSchemasEndpoint, func(ctx context.Context, _ postcard.Unit) (SchemaTotals, error) {
+		return d.sendSchemas(ctx), nil
+	})
+}
+
+// sendSchemas sends the schema listing to the host that asked for it. The
+// types come first, each distinct one once, then the endpoints and topics,
+// each group sorted by path.
+func (d *Dispatcher) sendSchemas(ctx context.Context) SchemaTotals {
+	d.mu.RLock()
+	var endpoints, topicsIn, topicsOut []*route
+	keys := map[*route]Key{}
+	for key, r := range d.routes {
+		keys[r] = key
+		if r.topic {
+			topicsIn = append(topicsIn, r)
+		} else {
+			endpoints = append(endpoints, r)
+		}
+	}
+	for key, r := range d.topicsOut {
+		keys[r] = key
+		topicsOut = append(topicsOut, r)
+	}
+	d.mu.RUnlock()
+	for _, rs := range [][]*route{endpoints, topicsIn, topicsOut} {
+		sort.Slice(rs, func(i, j int) bool { return rs[i].path < rs[j].path })
+	}
+
+	var totals SchemaTotals
+	send := func(entry SchemaData, sent *uint32) {
+		body, err := postcard.Serialize(&entry)
+		if err == nil {
+			err = d.reply(ctx, GetAllSchemaDataTopic.Key, body)
+		}
+		if err != nil {
+			totals.Errors++
+			return
+		}
+		*sent++
+	}
+
+	var seen [][]byte
+	sendType := func(schema *postcard.Schema) {
+		if schema == nil {
+			// The handler's type has no schema.
+			totals.Errors++
+			return
+		}
+		encoded, err := postcard.Serialize(NamedType{Schema: schema})
+		if err != nil {
+			totals.Errors++
+			return
+		}
+		for _, b := range seen {
+			if bytes.Equal(b, encoded) {
+				return
+			}
+		}
+		seen = append(seen, encoded)
+		send(SchemaDataType{Value: NamedType{Schema: schema}}, &totals.TypesSent)
+	}
+	for _, r := range endpoints {
+		sendType(r.reqSchema)
+		sendType(r.respSchema)
+	}
+	for _, r := range append(topicsIn, topicsOut...) {
+		sendType(r.reqSchema)
+	}
+
+	for _, r := range endpoints {
+		send(SchemaDataEndpoint{Path: r.path, RequestKey: keys[r], ResponseKey: r.respKey}, &totals.EndpointsSent)
+	}
+	for _, r := range topicsIn {
+		send(SchemaDataTopic{Path: r.path, Key: keys[r], Direction: TopicToServer}, &totals.TopicsInSent)
+	}
+	for _, r := range topicsOut {
+		send(SchemaDataTopic{Path: r.path, Key: keys[r], Direction: TopicToClient}, &totals.TopicsOutSent)
+	}
+	return totals
+}
+
+// Ping sends n to the device's ping endpoint and returns the echo.
+func Ping(ctx context.Context, c *Client, n uint32) (uint32, error) {
+	return Call(ctx, c, PingEndpoint, n)
+}
+
+// Schemas is a device's schema listing as collected by GetSchemas.
+type Schemas struct {
+	Types     []*postcard.Schema
+	Endpoints []SchemaDataEndpoint
+	TopicsIn  []SchemaDataTopic
+	TopicsOut []SchemaDataTopic
+}
+
+// GetSchemas fetches the device's schema listing. It fails if entries went
+// missing on the way, so a listing it returns is complete. Listings must not
+// be fetched concurrently on one client, since they arrive on a shared topic.
+func GetSchemas(ctx context.Context, c *Client) (*Schemas, error) {
+	sub := Subscribe(c, GetAllSchemaDataTopic, 256, DropNewest)
+	done := make(chan *Schemas)
+	go func() {
+		s := &Schemas{}
+		for entry := range sub.C {
+			switch e := entry.(type) {
+			case SchemaDataType:
+				s.Types = append(s.Types, e.Value.Schema)
+			case SchemaDataEndpoint:
+				s.Endpoints = append(s.Endpoints, e)
+			case SchemaDataTopic:
+				if e.Direction == TopicToServer {
+					s.TopicsIn = append(s.TopicsIn, e)
+				} else {
+					s.TopicsOut = append(s.TopicsOut, e)
+				}
+			}
+		}
+		done <- s
+	}()
+	// The device sends the listing before the reply, and the client delivers
+	// topic messages in the order they arrive, so all of it has been handed
+	// to the subscription once Call returns.
+	totals, err := Call(ctx, c, GetAllSchemasEndpoint, postcard.Unit{})
+	sub.Unsubscribe()
+	s := <-done
+	if err != nil {
+		return nil, err
+	}
+	if n := sub.Dropped(); n > 0 {
+		return nil, fmt.Errorf("rpc: schema listing: dropped %d entries", n)
+	}
+	got := SchemaTotals{
+		TypesSent:     uint32(len(s.Types)),
+		EndpointsSent: uint32(len(s.Endpoints)),
+		TopicsInSent:  uint32(len(s.TopicsIn)),
+		TopicsOutSent: uint32(len(s.TopicsOut)),
+		Errors:        totals.Errors,
+	}
+	if got != totals {
+		return nil, fmt.Errorf("rpc: schema listing: received %+v, device sent %+v", got, totals)
+	}
+	if totals.Errors > 0 {
+		return nil, fmt.Errorf("rpc: schema listing: device failed to send %d entries", totals.Errors)
+	}
+	return s, nil
+}
+
+// Endpoint finds the endpoint served at path.
+func (s *Schemas) Endpoint(path string) (SchemaDataEndpoint, bool) {
+	for _, e := range s.Endpoints {
+		if e.Path == path {
+			return e, true
+		}
+	}
+	return SchemaDataEndpoint{}, false
+}
+
+// Type finds the listed type whose messages on path have the given key, that
+// is, the schema of an endpoint's request or response or of a topic's
+// messages.
+func (s *Schemas) Type(path string, key Key) (*postcard.Schema, bool) {
+	for _, t := range s.Types {
+		if KeyForPath(path, t) == key {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Supports reports whether s lists ep with the keys the host computes, that
+// is, whether both sides agree on its path and types.
+func Supports[Req, Resp any](s *Schemas, ep Endpoint[Req, Resp]) bool {
+	e, ok := s.Endpoint(ep.Path)
+	return ok && e.RequestKey == ep.ReqKey && e.ResponseKey == ep.RespKey
+}
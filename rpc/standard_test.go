@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+func TestStandardEndpoints(t *testing.T) {
+	d := newClientTestDispatcher(t, nil)
+	if err := RegisterStandard(d); err != nil {
+		t.Fatal(err)
+	}
+	if err := HandleTopic(d, ledTopic, func(context.Context, bool) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeclareTopic(d, tempTopic); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeclareTopic(d, tempTopic); err == nil {
+		t.Error("declaring a topic twice succeeded")
+	}
+	if err := RegisterStandard(d); err == nil {
+		t.Error("registering the standard endpoints twice succeeded")
+	}
+	a, b := Pipe()
+	c := serveTransports(t, d, a, b)
+	ctx := context.Background()
+
+	if n, err := Ping(ctx, c, 42); err != nil || n != 42 {
+		t.Errorf("Ping = %d, %v", n, err)
+	}
+
+	s, err := GetSchemas(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, e := range s.Endpoints {
+		paths = append(paths, e.Path)
+	}
+	want := []string{"postcard-rpc/ping", "postcard-rpc/schemas/get", "test/block", "test/echo"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("endpoints = %v, want %v", paths, want)
+	}
+	if len(s.TopicsIn) != 1 || s.TopicsIn[0].Path != ledTopic.Path || s.TopicsIn[0].Key != ledTopic.Key {
+		t.Errorf("topics in = %+v", s.TopicsIn)
+	}
+	if len(s.TopicsOut) != 1 || s.TopicsOut[0].Key != tempTopic.Key {
+		t.Errorf("topics out = %+v", s.TopicsOut)
+	}
+	// u32, u8, (), SchemaTotals, bool and i16, each sent once.
+	if len(s.Types) != 6 {
+		t.Errorf("listed %d types, want 6", len(s.Types))
+	}
+
+	// The listed types reproduce the keys.
+	for _, e := range s.Endpoints {
+		if _, ok := s.Type(e.Path, e.RequestKey); !ok {
+			t.Errorf("%s: no listed type matches the request key", e.Path)
+		}
+		if _, ok := s.Type(e.Path, e.ResponseKey); !ok {
+			t.Errorf("%s: no listed type matches the response key", e.Path)
+		}
+	}
+	for _, topic := range append(s.TopicsIn, s.TopicsOut...) {
+		if _, ok := s.Type(topic.Path, topic.Key); !ok {
+			t.Errorf("%s: no listed type matches the key", topic.Path)
+		}
+	}
+
+	if !Supports(s, echoEndpoint) || !Supports(s, GetAllSchemasEndpoint) {
+		t.Error("Supports rejected a served endpoint")
+	}
+	if Supports(s, MustEndpoint[uint16, uint32]("test/echo")) {
+		t.Error("Supports accepted an endpoint with other types")
+	}
+	if Supports(s, addEndpoint) {
+		t.Error("Supports accepted an endpoint that is not served")
+	}
+}
+
+// TestGetSchemasConcurrentHosts checks that the listing goes only to the host
+// that asked for it.
+func TestGetSchemasConcurrentHosts(t *testing.T) {
+	d := NewDispatcher()
+	if err := RegisterStandard(d); err != nil {
+		t.Fatal(err)
+	}
+	var clients []*Client
+	for i := 0; i < 3; i++ {
+		a, b := Pipe()
+		clients = append(clients, serveTransports(t, d, a, b))
+	}
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				if _, err := GetSchemas(context.Background(), c); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+}
+
+func TestNamedType(t *testing.T) {
+	point := &postcard.Schema{Name: "P", Kind: postcard.SchemaStruct, Fields: []postcard.SchemaField{
+		{Name: "a", Type: &postcard.Schema{Name: "u8", Kind: postcard.SchemaU8}},
+	}}
+	encoded, err := postcard.Serialize(NamedType{Schema: point})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The name, DataModelType::Struct, then each field's name and type.
+	want := []byte{1, 'P', 26, 1, 1, 'a', 2, 'u', '8', 2}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("encoded % x, want % x", encoded, want)
+	}
+
+	type pair struct {
+		Left, Right [2]Key
+		Tag         postcard.Option[map[string][]int16]
+		Dir         TopicDirection
+	}
+	types := []*postcard.Schema{point}
+	for _, schema := range []func() (*postcard.Schema, error){
+		postcard.SchemaFor[pair],
+		postcard.SchemaFor[SchemaData],
+		postcard.SchemaFor[postcard.Result[postcard.Unit, string]],
+	} {
+		s, err := schema()
+		if err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, s)
+	}
+	for _, s := range types {
+		encoded, err := postcard.Serialize(NamedType{Schema: s})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded NamedType
+		if err := postcard.Deserialize(encoded, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded.Schema, s) {
+			t.Errorf("%s did not round-trip", s.Name)
+		}
+	}
+
+	bad := [][]byte{
+		{0, 29},
+		{0, 26, 1, 1, 'a'},
+		{0, 27, 1, 1, 'V', 4},
+		bytes.Repeat([]byte{0, 18}, maxSchemaDepth+2),
+	}
+	for i, b := range bad {
+		var decoded NamedType
+		if err := postcard.Deserialize(b, &decoded); err == nil {
+			t.Errorf("bad schema %d decoded", i)
+		}
+	}
+	if _, err := postcard.Serialize(NamedType{Schema: &postcard.Schema{Kind: postcard.SchemaSeq}}); !errors.Is(err, errBadSchema) {
+		t.Errorf("encoding a Seq without an element: error = %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Golden keys for TestGoldenKeys in key_test.go, worked out apart from the Go
+// package. Regenerate them with
+//
+//     rustc --edition 2021 -O rpc/testdata/keys.rs -o /tmp/keys && /tmp/keys
+//
+// and paste the printed bytes into the test. It needs only the standard
+// library.
+//
+// Hand-written schemas in postcard-schema 0.2's DataModelType shape, built
+// the way #[derive(Schema)] would for the Rust declarations, hashed the way
+// postcard-rpc's hash_ty_path does.
+enum T {
+    U8, U16, U32, I16, F32, String, Unit, Schema,
+    Tuple(Vec<T>),
+    Option(Box<T>),
+    NewtypeStruct(Box<T>),
+    Struct(Vec<(&'static str, T)>),
+    Enum(Vec<(&'static str, V)>),
+}
+enum V { Unit, Newtype(T), Tuple(Vec<T>), Struct(Vec<(&'static str, T)>) }
+
+fn upd(mut s: u64, d: &[u8]) -> u64 {
+    for b in d { s ^= *b as u64; s = s.wrapping_mul(0x0000_0100_0000_01b3); }
+    s
+}
+fn h(s: u64, t: &T) -> u64 {
+    match t {
+        T::U8 => upd(s, &[2]),
+        T::I16 => upd(s, &[3]),
+        T::U16 => upd(s, &[7]),
+        T::U32 => upd(s, &[8]),
+        T::F32 => upd(s, &[13]),
+        T::String => upd(s, &[16]),
+        T::Unit => upd(s, &[19]),
+        T::Schema => upd(s, &[28]),
+        T::Tuple(es) => es.iter().fold(upd(s, &[23]), |s, e| h(s, e)),
+        T::Option(e) => h(upd(s, &[18]), e),
+        T::NewtypeStruct(e) => h(upd(s, &[21]), e),
+        T::Struct(fs) => fields(upd(s, &[26]), fs),
+        T::Enum(vs) => {
+            let mut s = upd(s, &[27]);
+            for (n, v) in vs {
+                s = upd(s, n.as_bytes());
+                s = match v {
+                    V::Unit => upd(s, &[0]),
+                    V::Newtype(e) => h(upd(s, &[1]), e),
+                    V::Tuple(es) => es.iter().fold(upd(s, &[2]), |s, e| h(s, e)),
+                    V::Struct(fs) => fields(upd(s, &[3]), fs),
+                };
+            }
+            s
+        }
+    }
+}
+fn fields(s: u64, fs: &[(&'static str, T)]) -> u64 {
+    fs.iter().fold(s, |s, (n, t)| h(upd(s, n.as_bytes()), t))
+}
+fn key(path: &str, t: &T) -> String {
+    let k = h(upd(0xcbf2_9ce4_8422_2325, path.as_bytes()), t).to_le_bytes();
+    k.iter().map(|b| format!("0x{:02x}", b)).collect::<Vec<_>>().join(", ")
+}
+
+fn register_write() -> T { T::Struct(vec![("addr", T::U32), ("value", T::U16)]) }
+fn meters() -> T { T::NewtypeStruct(Box::new(T::F32)) }
+fn command() -> T {
+    T::Enum(vec![
+        ("Ping", V::Unit),
+        ("Reset", V::Struct(vec![("delay_ms", T::U32)])),
+        ("Write", V::Newtype(register_write())),
+        ("Move", V::Tuple(vec![T::I16, T::I16])),
+        ("Calibrate", V::Newtype(T::Option(Box::new(meters())))),
+    ])
+}
+fn mode() -> T { T::Enum(vec![("Idle", V::Unit), ("Sampling", V::Unit), ("Fault", V::Unit)]) }
+fn ack() -> T {
+    T::NewtypeStruct(Box::new(T::Enum(vec![("Ok", V::Newtype(T::Unit)), ("Err", V::Newtype(mode()))])))
+}
+fn wire_error() -> T {
+    T::Enum(vec![
+        ("FrameTooLong", V::Newtype(T::Struct(vec![("len", T::U32), ("max", T::U32)]))),
+        ("FrameTooShort", V::Newtype(T::Struct(vec![("len", T::U32)]))),
+        ("DeserFailed", V::Unit),
+        ("SerFailed", V::Unit),
+        ("UnknownKey", V::Unit),
+        ("FailedToSpawn", V::Unit),
+        ("KeyTooSmall", V::Unit),
+    ])
+}
+
+// postcard-rpc's Key, a newtype around [u8; 8], which is a tuple of eight u8.
+fn rpc_key() -> T { T::NewtypeStruct(Box::new(T::Tuple((0..8).map(|_| T::U8).collect()))) }
+fn schema_totals() -> T {
+    T::Struct(vec![
+        ("types_sent", T::U32),
+        ("endpoints_sent", T::U32),
+        ("topics_in_sent", T::U32),
+        ("topics_out_sent", T::U32),
+        ("errors", T::U32),
+    ])
+}
+fn schema_data() -> T {
+    T::Enum(vec![
+        ("Type", V::Newtype(T::Schema)),
+        ("Endpoint", V::Struct(vec![("path", T::String), ("request_key", rpc_key()), ("response_key", rpc_key())])),
+        ("Topic", V::Struct(vec![
+            ("path", T::String),
+            ("key", rpc_key()),
+            ("direction", T::Enum(vec![("ToServer", V::Unit), ("ToClient", V::Unit)])),
+        ])),
+    ])
+}
+
+fn main() {
+    println!("ping u32       {}", key("postcard-rpc/ping", &T::U32));
+    println!("error          {}", key("error", &wire_error()));
+    println!("command req    {}", key("device/command", &command()));
+    println!("command resp   {}", key("device/command", &T::NewtypeStruct(Box::new(T::Option(Box::new(command()))))));
+    println!("ack topic      {}", key("device/ack", &ack()));
+    println!("schemas req    {}", key("postcard-rpc/schemas/get", &T::Unit));
+    println!("schemas resp   {}", key("postcard-rpc/schemas/get", &schema_totals()));
+    println!("schema data    {}", key("postcard-rpc/schema/data", &schema_data()));
+}
@@ -0,0 +1,239 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+// Topic describes unsolicited messages sent on Path in either direction.
+type Topic[T any] struct {
+	Path string
+	Key  Key
+}
+
+func NewTopic[T any](path string) (Topic[T], error) {
+	key, err := KeyFor[T](path)
+	if err != nil {
+		return Topic[T]{}, err
+	}
+	return Topic[T]{Path: path, Key: key}, nil
+}
+
+// MustTopic is like NewTopic but panics if T has no schema.
+func MustTopic[T any](path string) Topic[T] {
+	t, err := NewTopic[T](path)
+	if err != nil {
+		panic("rpc: topic " + path + ": " + err.Error())
+	}
+	return t
+}
+
+// Publisher is a Client publishing to the device or a Dispatcher publishing
+// to the hosts it serves.
+type Publisher interface {
+	publish(key Key, body []byte) error
+}
+
+// Publish sends msg on topic t.
+func Publish[T any](p Publisher, t Topic[T], msg T) error {
+	body, err := postcard.Serialize(&msg)
+	if err != nil {
+		return err
+	}
+	return p.publish(t.Key, body)
+}
+
+func (c *Client) publish(key Key, body []byte) error {
+	frame, err := frameWithBody(WireHeader{Key: key, SeqNo: c.topicSeq.Add(1)}, body)
+	if err != nil {
+		return err
+	}
+	if err := c.closeErr(); err != nil {
+		return err
+	}
+	return c.send(frame)
+}
+
+// publish sends the message on every transport being served. It returns the
+// first send error; the transport that failed is dropped by Serve.
+func (d *Dispatcher) publish(key Key, body []byte) error {
+	frame, err := frameWithBody(WireHeader{Key: key, SeqNo: d.seq.Add(1)}, body)
+	if err != nil {
+		return err
+	}
+	d.mu.RLock()
+	conns := make([]Transport, 0, len(d.conns))
+	for t := range d.conns {
+		conns = append(conns, t)
+	}
+	d.mu.RUnlock()
+
+	var first error
+	for _, t := range conns {
+		if err := t.Send(frame); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// HandleTopic registers fn to receive the messages hosts publish on t.
+func HandleTopic[T any](d *Dispatcher, t Topic[T], fn func(ctx context.Context, msg T)) error {
+	rt := &route{path: t.Path, topic: true}
+	rt.reqSchema, _ = postcard.SchemaFor[T]()
+	rt.handler = func(ctx context.Context, r *Request) ([]byte, error) {
+		var msg T
+		if err := postcard.Deserialize(r.Body, &msg); err != nil {
+			return nil, err
+		}
+		fn(ctx, msg)
+		return nil, nil
+	}
+	return d.register(t.Key, rt)
+}
+
+// DeclareTopic lists t among the topics d publishes, so hosts can discover
+// it through the standard endpoints. Publishing does not require it.
+func DeclareTopic[T any](d *Dispatcher, t Topic[T]) error {
+	schema, err := postcard.SchemaFor[T]()
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if old, ok := d.topicsOut[t.Key]; ok {
+		return fmt.Errorf("rpc: key %v of %q already used by %q", t.Key, t.Path, old.path)
+	}
+	d.topicsOut[t.Key] = &route{path: t.Path, topic: true, reqSchema: schema}
+	return nil
+}
+
+// Policy decides what a subscription does with a message that arrives while
+// its buffer is full. Delivery never blocks the connection.
+type Policy int
+
+const (
+	// DropNewest discards the arriving message.
+	DropNewest Policy = iota
+	// DropOldest discards the oldest buffered message to make room, so a
+	// lagging subscriber sees the most recent messages.
+	DropOldest
+)
+
+type subscriber interface {
+	deliver(body []byte)
+	close()
+}
+
+// Subscription receives the messages the device publishes on a topic.
+type Subscription[T any] struct {
+	// C delivers the messages. It is closed by Unsubscribe or when the
+	// client stops.
+	C <-chan T
+
+	ch      chan T
+	policy  Policy
+	dropped atomic.Uint64
+	client  *Client
+	key     Key
+}
+
+// Subscribe starts buffering up to buffer messages published on t. The
+// buffer holds at least one message.
+func Subscribe[T any](c *Client, t Topic[T], buffer int, policy Policy) *Subscription[T] {
+	if buffer < 1 {
+		buffer = 1
+	}
+	ch := make(chan T, buffer)
+	s := &Subscription[T]{C: ch, ch: ch, policy: policy, client: c, key: t.Key}
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.closeErr() != nil {
+		close(ch)
+		return s
+	}
+	if c.subs[t.Key] == nil {
+		c.subs[t.Key] = map[subscriber]struct{}{}
+	}
+	c.subs[t.Key][s] = struct{}{}
+	return s
+}
+
+// Dropped returns how many messages were discarded by the policy or because
+// they failed to decode.
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe stops delivery and closes C. Buffered messages can still be
+// received.
+func (s *Subscription[T]) Unsubscribe() {
+	c := s.client
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if _, ok := c.subs[s.key][s]; !ok {
+		return
+	}
+	delete(c.subs[s.key], s)
+	if len(c.subs[s.key]) == 0 {
+		delete(c.subs, s.key)
+	}
+	close(s.ch)
+}
+
+// deliver is called by the client's read loop with subMu held, so it must
+// not block.
+func (s *Subscription[T]) deliver(body []byte) {
+	var msg T
+	if err := postcard.Deserialize(body, &msg); err != nil {
+		s.dropped.Add(1)
+		return
+	}
+	for {
+		select {
+		case s.ch <- msg:
+			return
+		default:
+		}
+		if s.policy == DropNewest {
+			s.dropped.Add(1)
+			return
+		}
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+func (s *Subscription[T]) close() {
+	close(s.ch)
+}
+
+// dispatchTopic hands a frame to the subscribers of its key and reports
+// whether there were any.
+func (c *Client) dispatchTopic(hdr WireHeader, body []byte) bool {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	subs := c.subs[hdr.Key]
+	for s := range subs {
+		s.deliver(body)
+	}
+	return len(subs) > 0
+}
+
+// closeSubs closes every subscription once the client has stopped.
+func (c *Client) closeSubs() {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	for key, subs := range c.subs {
+		for s := range subs {
+			s.close()
+		}
+		delete(c.subs, key)
+	}
+}
@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+var (
+	tempTopic = MustTopic[int16]("sensor/temp")
+	ledTopic  = MustTopic[bool]("led/set")
+)
+
+func recvTimeout[T any](t *testing.T, ch <-chan T) (T, bool) {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a message")
+	}
+	panic("unreachable")
+}
+
+func TestTopicDeviceToHost(t *testing.T) {
+	d := newClientTestDispatcher(t, nil)
+	c, _ := startPipe(t, d)
+	sub := Subscribe(c, tempTopic, 8, DropNewest)
+	other := Subscribe(c, tempTopic, 8, DropNewest)
+
+	// Serve registers the connection asynchronously; a round trip makes
+	// sure it is there before publishing.
+	if _, err := Call(context.Background(), c, echoEndpoint, 1); err != nil {
+		t.Fatal(err)
+	}
+	for i := int16(-2); i <= 2; i++ {
+		if err := Publish(d, tempTopic, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := int16(-2); i <= 2; i++ {
+		if got, _ := recvTimeout(t, sub.C); got != i {
+			t.Errorf("sub got %d, want %d", got, i)
+		}
+		if got, _ := recvTimeout(t, other.C); got != i {
+			t.Errorf("other got %d, want %d", got, i)
+		}
+	}
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	if _, ok := recvTimeout(t, sub.C); ok {
+		t.Error("C is open after Unsubscribe")
+	}
+	if err := Publish(d, tempTopic, 9); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := recvTimeout(t, other.C); got != 9 {
+		t.Errorf("other got %d, want 9", got)
+	}
+	// Calls keep working next to topic traffic.
+	if got, err := Call(context.Background(), c, echoEndpoint, 5); err != nil || got != 5 {
+		t.Errorf("Call = %d, %v", got, err)
+	}
+
+	c.Close()
+	if _, ok := recvTimeout(t, other.C); ok {
+		t.Error("C is open after the client closed")
+	}
+	late := Subscribe(c, tempTopic, 1, DropNewest)
+	if _, ok := recvTimeout(t, late.C); ok {
+		t.Error("subscription on a closed client is open")
+	}
+	if err := Publish(c, ledTopic, true); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish on a closed client error = %v", err)
+	}
+}
+
+func TestTopicHostToDevice(t *testing.T) {
+	d := NewDispatcher()
+	got := make(chan bool, 1)
+	err := HandleTopic(d, ledTopic, func(ctx context.Context, on bool) { got <- on })
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := startPipe(t, d)
+	if err := Publish(c, ledTopic, true); err != nil {
+		t.Fatal(err)
+	}
+	if on, _ := recvTimeout(t, got); !on {
+		t.Error("handler got false")
+	}
+}
+
+func TestSubscriptionPolicy(t *testing.T) {
+	c, _ := startPipe(t, NewDispatcher())
+	body := func(n int16) []byte {
+		b, err := postcard.Serialize(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		policy Policy
+		want   []int16
+	}{
+		{DropNewest, []int16{0, 1}},
+		{DropOldest, []int16{3, 4}},
+	}
+	for _, tt := range tests {
+		s := Subscribe(c, tempTopic, 2, tt.policy)
+		for i := int16(0); i < 5; i++ {
+			s.deliver(body(i))
+		}
+		s.deliver([]byte{0x80})
+		s.Unsubscribe()
+		var got []int16
+		for v := range s.C {
+			got = append(got, v)
+		}
+		if len(got) != 2 || got[0] != tt.want[0] || got[1] != tt.want[1] {
+			t.Errorf("policy %d delivered %v, want %v", tt.policy, got, tt.want)
+		}
+		if s.Dropped() != 4 {
+			t.Errorf("policy %d dropped %d, want 4", tt.policy, s.Dropped())
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package rpc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// MaxFrameLen bounds the frames the stream transports accept, so a corrupt
+// length or a missing delimiter cannot exhaust memory.
+const MaxFrameLen = 1 << 20
+
+var ErrFrameTooLong = errors.New("rpc: frame too long")
+
+// Transport carries whole frames. Send may be called concurrently, and
+// concurrently with Recv. Close unblocks pending calls.
+type Transport interface {
+	Send(frame []byte) error
+	Recv() ([]byte, error)
+	Close() error
+}
+
+// streamTransport delimits frames with a varint length prefix.
+type streamTransport struct {
+	rwc io.ReadWriteCloser
+	r   *bufio.Reader
+	wmu sync.Mutex
+}
+
+// NewStreamTransport carries frames over a reliable byte stream such as a
+// net.Conn, prefixing each with its length as a varint.
+func NewStreamTransport(rwc io.ReadWriteCloser) Transport {
+	return &streamTransport{rwc: rwc, r: bufio.NewReader(rwc)}
+}
+
+func (t *streamTransport) Send(frame []byte) error {
+	if len(frame) > MaxFrameLen {
+		return fmt.Errorf("%w: %d bytes", ErrFrameTooLong, len(frame))
+	}
+	buf := make([]byte, 0, binary.MaxVarintLen32+len(frame))
+	buf = binary.AppendUvarint(buf, uint64(len(frame)))
+	buf = append(buf, frame...)
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	_, err := t.rwc.Write(buf)
+	return err
+}
+
+func (t *streamTransport) Recv() ([]byte, error) {
+	n, err := binary.ReadUvarint(t.r)
+	if err != nil {
+		return nil, err
+	}
+	if n > MaxFrameLen {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFrameTooLong, n)
+	}
+	frame := make([]byte, n)
+	if _, err := io.ReadFull(t.r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+func (t *streamTransport) Close() error {
+	return t.rwc.Close()
+}
+
+// pipeTransport is one end of Pipe.
+type pipeTransport struct {
+	in   <-chan []byte
+	out  chan<- []byte
+	done chan struct{}
+	once *sync.Once
+}
+
+// Pipe returns two connected in-memory transports. Closing either end
+// closes both.
+func Pipe() (Transport, Transport) {
+	ab := make(chan []byte, 16)
+	ba := make(chan []byte, 16)
+	done := make(chan struct{})
+	once := new(sync.Once)
+	return &pipeTransport{in: ba, out: ab, done: done, once: once},
+		&pipeTransport{in: ab, out: ba, done: done, once: once}
+}
+
+func (t *pipeTransport) Send(frame []byte) error {
+	frame = append([]byte(nil), frame...)
+	select {
+	case <-t.done:
+		return io.ErrClosedPipe
+	default:
+	}
+	select {
+	case t.out <- frame:
+		return nil
+	case <-t.done:
+		return io.ErrClosedPipe
+	}
+}
+
+func (t *pipeTransport) Recv() ([]byte, error) {
+	select {
+	case <-t.done:
+		return nil, io.EOF
+	default:
+	}
+	select {
+	case frame := <-t.in:
+		return frame, nil
+	case <-t.done:
+		return nil, io.EOF
+	}
+}
+
+func (t *pipeTransport) Close() error {
+	t.once.Do(func() { close(t.done) })
+	return nil
+}
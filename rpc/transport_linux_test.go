@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// openPTY allocates a pseudo-terminal in raw mode and returns the name of
+// its device alongside the controlling side.
+func openPTY() (*os.File, string, error) {
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	var n uint32
+	var unlock int32
+	if err := ioctl(ptmx, syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		ptmx.Close()
+		return nil, "", err
+	}
+	if err := ioctl(ptmx, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		ptmx.Close()
+		return nil, "", err
+	}
+	var tio syscall.Termios
+	if err := ioctl(ptmx, syscall.TCGETS, unsafe.Pointer(&tio)); err != nil {
+		ptmx.Close()
+		return nil, "", err
+	}
+	// The equivalent of cfmakeraw.
+	tio.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	tio.Oflag &^= syscall.OPOST
+	tio.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	tio.Cflag &^= syscall.CSIZE | syscall.PARENB
+	tio.Cflag |= syscall.CS8
+	if err := ioctl(ptmx, syscall.TCSETS, unsafe.Pointer(&tio)); err != nil {
+		ptmx.Close()
+		return nil, "", err
+	}
+	return ptmx, fmt.Sprintf("/dev/pts/%d", n), nil
+}
+
+func TestSerialTransportPTY(t *testing.T) {
+	ptmx, name, err := openPTY()
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	device, err := OpenSerial(name)
+	if err != nil {
+		ptmx.Close()
+		t.Skipf("opening %s: %v", name, err)
+	}
+	testTransports(t, "pty", NewCOBSTransport(ptmx), device)
+}
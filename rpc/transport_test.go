@@ -0,0 +1,160 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestCOBS(t *testing.T) {
+	seq := func(from, to int) []byte {
+		var b []byte
+		for i := from; i <= to; i++ {
+			b = append(b, byte(i))
+		}
+		return b
+	}
+	cat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	tests := []struct {
+		name    string
+		decoded []byte
+		encoded []byte
+	}{
+		{"empty", nil, []byte{0x01}},
+		{"zero", []byte{0x00}, []byte{0x01, 0x01}},
+		{"two zeros", []byte{0x00, 0x00}, []byte{0x01, 0x01, 0x01}},
+		{"inner zero", []byte{0x11, 0x22, 0x00, 0x33}, []byte{0x03, 0x11, 0x22, 0x02, 0x33}},
+		{"no zero", []byte{0x11, 0x22, 0x33, 0x44}, []byte{0x05, 0x11, 0x22, 0x33, 0x44}},
+		{"trailing zeros", []byte{0x11, 0x00, 0x00, 0x00}, []byte{0x02, 0x11, 0x01, 0x01, 0x01}},
+		{"254 bytes", seq(1, 254), cat([]byte{0xFF}, seq(1, 254))},
+		{"255 bytes", seq(0, 254), cat([]byte{0x01, 0xFF}, seq(1, 254))},
+		{"255 nonzero", seq(1, 255), cat([]byte{0xFF}, seq(1, 254), []byte{0x02, 0xFF})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := cobsEncode(nil, tt.decoded)
+			if !bytes.Equal(encoded, tt.encoded) {
+				t.Errorf("cobsEncode = %x, want %x", encoded, tt.encoded)
+			}
+			decoded, err := cobsDecode(tt.encoded)
+			if err != nil || !bytes.Equal(decoded, tt.decoded) {
+				t.Errorf("cobsDecode = %x, %v, want %x", decoded, err, tt.decoded)
+			}
+		})
+	}
+
+	for _, bad := range [][]byte{{0x00}, {0x05, 0x11}, {0x03, 0x11, 0x00}} {
+		if _, err := cobsDecode(bad); err == nil {
+			t.Errorf("cobsDecode(%x) succeeded", bad)
+		}
+	}
+}
+
+func TestCOBSTransportResync(t *testing.T) {
+	a, b := net.Pipe()
+	recv := NewCOBSTransport(b)
+	defer recv.Close()
+	go func() {
+		// Noise, an empty packet and a malformed packet are skipped.
+		a.Write([]byte{0x00, 0x00, 0x05, 0x11, 0x00})
+		a.Write(append(cobsEncode(nil, []byte{1, 0, 2}), 0))
+		a.Close()
+	}()
+	frame, err := recv.Recv()
+	if err != nil || !bytes.Equal(frame, []byte{1, 0, 2}) {
+		t.Errorf("Recv = %x, %v", frame, err)
+	}
+	if _, err := recv.Recv(); err != io.EOF {
+		t.Errorf("Recv at end = %v, want EOF", err)
+	}
+}
+
+func TestStreamTransportLimits(t *testing.T) {
+	a, b := net.Pipe()
+	send, recv := NewStreamTransport(a), NewStreamTransport(b)
+	defer send.Close()
+	defer recv.Close()
+	if err := send.Send(make([]byte, MaxFrameLen+1)); !errors.Is(err, ErrFrameTooLong) {
+		t.Errorf("Send error = %v, want ErrFrameTooLong", err)
+	}
+	go a.Write([]byte{0xFF, 0xFF, 0xFF, 0x7F})
+	if _, err := recv.Recv(); !errors.Is(err, ErrFrameTooLong) {
+		t.Errorf("Recv error = %v, want ErrFrameTooLong", err)
+	}
+}
+
+func TestPipeTransport(t *testing.T) {
+	a, b := Pipe()
+	frame := []byte{1, 2, 3}
+	if err := a.Send(frame); err != nil {
+		t.Fatal(err)
+	}
+	frame[0] = 9
+	if got, err := b.Recv(); err != nil || !bytes.Equal(got, []byte{1, 2, 3}) {
+		t.Errorf("Recv = %x, %v", got, err)
+	}
+	b.Close()
+	if err := a.Send(frame); err != io.ErrClosedPipe {
+		t.Errorf("Send after Close = %v", err)
+	}
+	if _, err := a.Recv(); err != io.EOF {
+		t.Errorf("Recv after Close = %v", err)
+	}
+}
+
+// testTransports runs calls and topics through a client and server
+// connected by each kind of transport.
+func testTransports(t *testing.T, name string, client, server Transport) {
+	t.Run(name, func(t *testing.T) {
+		d := newClientTestDispatcher(t, nil)
+		c := serveTransports(t, d, client, server)
+		sub := Subscribe(c, tempTopic, 4, DropNewest)
+		for i := uint32(0); i < 300; i += 100 {
+			if got, err := Call(context.Background(), c, echoEndpoint, i); err != nil || got != i {
+				t.Fatalf("Call(%d) = %d, %v", i, got, err)
+			}
+		}
+		if err := Publish(d, tempTopic, -5); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := recvTimeout(t, sub.C); got != -5 {
+			t.Errorf("topic got %d", got)
+		}
+	})
+}
+
+func TestTransports(t *testing.T) {
+	a, b := Pipe()
+	testTransports(t, "memory", a, b)
+
+	ca, cb := net.Pipe()
+	testTransports(t, "cobs", NewCOBSTransport(ca), NewCOBSTransport(cb))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConn, ok := <-accepted
+	if !ok {
+		t.Fatal("accept failed")
+	}
+	testTransports(t, "tcp", NewStreamTransport(conn), NewStreamTransport(serverConn))
+}
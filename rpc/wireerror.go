@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"fmt"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+// ErrorPath is the path error replies are keyed by, as in postcard-rpc.
+const ErrorPath = "error"
+
+// WireError is the error reply of postcard-rpc. A request that fails is
+// answered with a WireError under ErrorKey and the request's sequence
+// number. The variants are the types below; they are Go errors, so callers
+// can pick them out with errors.As.
+type WireError interface {
+	error
+	isWireError()
+}
+
+// FrameTooLong reports a request larger than the device accepts.
+type FrameTooLong struct {
+	Len uint32
+	Max uint32
+}
+
+// FrameTooShort reports a frame too short to hold a header.
+type FrameTooShort struct {
+	Len uint32
+}
+
+// DeserFailed reports a request body that did not decode.
+type DeserFailed struct{}
+
+// SerFailed reports a response that could not be encoded.
+type SerFailed struct{}
+
+// UnknownKey reports a request for a key with no handler.
+type UnknownKey struct{}
+
+// FailedToSpawn reports a handler that could not run. The Dispatcher also
+// sends it for handler errors that are not WireErrors, as postcard-rpc has
+// no closer variant.
+type FailedToSpawn struct{}
+
+// KeyTooSmall reports a key shorter than the device's key size.
+type KeyTooSmall struct{}
+
+func (e FrameTooLong) Error() string {
+	return fmt.Sprintf("rpc: frame of %d bytes exceeds the limit of %d", e.Len, e.Max)
+}
+
+func (e FrameTooShort) Error() string {
+	return fmt.Sprintf("rpc: frame of %d bytes is too short", e.Len)
+}
+
+func (DeserFailed) Error() string   { return "rpc: request failed to decode" }
+func (SerFailed) Error() string     { return "rpc: response failed to encode" }
+func (UnknownKey) Error() string    { return "rpc: no handler for key" }
+func (FailedToSpawn) Error() string { return "rpc: handler failed" }
+func (KeyTooSmall) Error() string   { return "rpc: key too small" }
+
+// Is makes a remote UnknownKey match ErrUnknownKey.
+func (UnknownKey) Is(target error) bool { return target == ErrUnknownKey }
+
+func (FrameTooLong) isWireError()  {}
+func (FrameTooShort) isWireError() {}
+func (DeserFailed) isWireError()   {}
+func (SerFailed) isWireError()     {}
+func (UnknownKey) isWireError()    {}
+func (FailedToSpawn) isWireError() {}
+func (KeyTooSmall) isWireError()   {}
+
+// Rust declares the two variants with data as newtypes around structs, so
+// they describe themselves to hash the same.
+
+func (FrameTooLong) PostcardSchema() (*postcard.Schema, error) {
+	u32 := &postcard.Schema{Name: "u32", Kind: postcard.SchemaU32}
+	return &postcard.Schema{Name: "FrameTooLong", Kind: postcard.SchemaStruct, Fields: []postcard.SchemaField{
+		{Name: "len", Type: u32},
+		{Name: "max", Type: u32},
+	}}, nil
+}
+
+func (FrameTooShort) PostcardSchema() (*postcard.Schema, error) {
+	u32 := &postcard.Schema{Name: "u32", Kind: postcard.SchemaU32}
+	return &postcard.Schema{Name: "FrameTooShort", Kind: postcard.SchemaStruct, Fields: []postcard.SchemaField{
+		{Name: "len", Type: u32},
+	}}, nil
+}
+
+// ErrorKey is the key of error replies.
+var ErrorKey = registerWireError()
+
+func registerWireError() Key {
+	postcard.RegisterEnum[WireError](
+		FrameTooLong{}, FrameTooShort{}, DeserFailed{}, SerFailed{},
+		UnknownKey{}, FailedToSpawn{}, KeyTooSmall{},
+	)
+	key, err := KeyFor[WireError](ErrorPath)
+	if err != nil {
+		panic("rpc: " + err.Error())
+	}
+	return key
+}
+
+// errorFrame encodes werr as the reply to the request with sequence number
+// seq.
+func errorFrame(seq uint32, werr WireError) ([]byte, error) {
+	return EncodeFrame(WireHeader{Key: ErrorKey, SeqNo: seq}, werr)
+}
@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yixinin/postcard-go/postcard"
+)
+
+func TestErrorKey(t *testing.T) {
+	// WireError as postcard-schema describes it: two newtype variants
+	// around structs, then unit variants.
+	data := []byte("error\x1b")
+	data = append(data, "FrameTooLong\x01\x1alen\x08max\x08"...)
+	data = append(data, "FrameTooShort\x01\x1alen\x08"...)
+	for _, name := range []string{"DeserFailed", "SerFailed", "UnknownKey", "FailedToSpawn", "KeyTooSmall"} {
+		data = append(data, name...)
+		data = append(data, 0)
+	}
+	if want := fnvKey(data); ErrorKey != want {
+		t.Errorf("ErrorKey = %v, want %v", ErrorKey, want)
+	}
+}
+
+// decodeErrorReply checks that reply is a WireError answering seq.
+func decodeErrorReply(t *testing.T, reply []byte, seq uint32) WireError {
+	t.Helper()
+	hdr, body, err := DecodeFrame(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr != (WireHeader{Key: ErrorKey, SeqNo: seq}) {
+		t.Errorf("error reply header = %+v", hdr)
+	}
+	var werr WireError
+	if err := postcard.Deserialize(body, &werr); err != nil {
+		t.Fatal(err)
+	}
+	return werr
+}
+
+func TestDispatchErrorReplies(t *testing.T) {
+	d := newTestDispatcher(t)
+	tooSmall := MustEndpoint[uint16, uint16]("test/too-small")
+	err := Handle(d, tooSmall, func(ctx context.Context, n uint16) (uint16, error) {
+		return 0, errors.Join(errOdd, KeyTooSmall{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		frame []byte
+		seq   uint32
+		want  WireError
+	}{
+		{"unknown key", mustFrame(t, WireHeader{Key: addEndpoint.RespKey, SeqNo: 4}, int8(0)), 4, UnknownKey{}},
+		{"bad body", mustFrame(t, WireHeader{Key: addEndpoint.ReqKey, SeqNo: 5}, int8(1)), 5, DeserFailed{}},
+		{"handler error", mustFrame(t, WireHeader{Key: addEndpoint.ReqKey, SeqNo: 6}, pingPoint{B: 1}), 6, FailedToSpawn{}},
+		{"handler wire error", mustFrame(t, WireHeader{Key: tooSmall.ReqKey, SeqNo: 7}, uint16(1)), 7, KeyTooSmall{}},
+		{"short frame", []byte{1, 2, 3}, 0, FrameTooShort{Len: 3}},
+	}
+	for _, tt := range tests {
+		reply, err := d.Dispatch(ctx, tt.frame)
+		if err == nil {
+			t.Errorf("%s: Dispatch succeeded", tt.name)
+			continue
+		}
+		if got := decodeErrorReply(t, reply, tt.seq); got != tt.want {
+			t.Errorf("%s: reply = %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+
+	// Topics get no reply.
+	if err := HandleTopic(d, ledTopic, func(context.Context, bool) {}); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := d.Dispatch(ctx, mustFrame(t, WireHeader{Key: ledTopic.Key}, uint8(7)))
+	if err == nil || reply != nil {
+		t.Errorf("bad topic message: reply %x, error %v", reply, err)
+	}
+}
+
+func mustFrame[T any](t *testing.T, h WireHeader, msg T) []byte {
+	t.Helper()
+	frame, err := EncodeFrame(h, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frame
+}
+
+func TestClientWireErrors(t *testing.T) {
+	c, _ := startPipe(t, newTestDispatcher(t))
+	ctx := context.Background()
+
+	_, err := Call(ctx, c, MustEndpoint[uint8, uint8]("test/missing"), 1)
+	var unknown UnknownKey
+	if !errors.As(err, &unknown) || !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("missing endpoint error = %v", err)
+	}
+
+	// Same keys as addEndpoint, but a request the handler cannot decode.
+	mismatched := Endpoint[int8, int32]{Path: addEndpoint.Path, ReqKey: addEndpoint.ReqKey, RespKey: addEndpoint.RespKey}
+	_, err = Call(ctx, c, mismatched, 1)
+	var werr WireError
+	if !errors.As(err, &werr) || werr != (DeserFailed{}) {
+		t.Errorf("mismatched request error = %v", err)
+	}
+
+	_, err = Call(ctx, c, addEndpoint, pingPoint{B: 3})
+	var failed FailedToSpawn
+	if !errors.As(err, &failed) {
+		t.Errorf("handler error = %v", err)
+	}
+
+	if sum, err := Call(ctx, c, addEndpoint, pingPoint{A: 1, B: 2}); err != nil || sum != 3 {
+		t.Errorf("Call after errors = %d, %v", sum, err)
+	}
+}